@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// checkItem est la représentation d'une vérification utilisée pour la sortie JSON de 'check'.
+type checkItem struct {
+	ShortCode      string               `json:"short_code"`
+	LongURL        string               `json:"long_url"`
+	Accessible     bool                 `json:"accessible"`
+	StatusCode     int                  `json:"status_code"`
+	Method         string               `json:"method"`
+	LatencyMs      int64                `json:"latency_ms"`
+	ErrorClass     string               `json:"error_class,omitempty"`
+	FinalURL       string               `json:"final_url"`
+	CheckedAt      time.Time            `json:"checked_at"`
+	RedirectChain  []models.RedirectHop `json:"redirect_chain,omitempty"`
+	HTTPSDowngrade bool                 `json:"https_downgrade"`
+	TLSExpiresAt   *time.Time           `json:"tls_expires_at,omitempty"`
+	ContentHash    string               `json:"content_hash,omitempty"`
+	PageTitle      string               `json:"page_title,omitempty"`
+}
+
+// CheckCmd représente la commande 'check'
+var CheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Vérifie immédiatement la destination d'un ou de tous les liens.",
+	Long: `Cette commande vérifie la destination des liens avec la même logique que le moniteur
+(statuts acceptés, repli en GET, User-Agent, limites par hôte) et affiche un rapport.
+Les résultats ne sont pas enregistrés dans l'historique : pour cela, utilisez
+POST /links/{shortCode}/check sur le serveur en cours d'exécution.
+Avec --all, les liens dont la surveillance est désactivée sont ignorés.
+La commande se termine avec le code 1 si au moins une destination est inaccessible.
+
+Exemples:
+  url-shortener check --code="xyz123"
+  url-shortener check --all
+  url-shortener check --all --format=json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shortCode, _ := cmd.Flags().GetString("code")
+		all, _ := cmd.Flags().GetBool("all")
+		format, _ := cmd.Flags().GetString("format")
+		if (shortCode == "") == !all {
+			log.Fatalf("FATAL: Indiquez soit --code, soit --all.")
+		}
+		if format != "table" && format != "json" {
+			log.Fatalf("FATAL: --format doit valoir 'table' ou 'json'.")
+		}
+
+		opts, err := monitor.OptionsFromConfig(cmd2.Cfg)
+		if err != nil {
+			log.Fatalf("FATAL: Configuration du moniteur invalide: %v", err)
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkRepo := repository.NewLinkRepository(db)
+		var links []models.Link
+		if all {
+			links, err = linkRepo.GetAllLinks()
+		} else {
+			var link *models.Link
+			link, err = services.NewLinkService(linkRepo).GetLinkByShortCode(shortCode)
+			if link != nil {
+				links = []models.Link{*link}
+			}
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", shortCode)
+				closeDB()
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la récupération des liens: %v", err)
+		}
+		if all {
+			monitored := links[:0]
+			for _, link := range links {
+				if link.Monitor.IsEnabled() {
+					monitored = append(monitored, link)
+				}
+			}
+			links = monitored
+		}
+
+		// Ctrl+C interrompt les vérifications en cours ; le rapport porte sur celles terminées
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, repository.NewLinkCheckRepository(db),
+			repository.NewMaintenanceWindowRepository(db), opts)
+		checks := make(map[uint]models.LinkCheck, len(links))
+		urlMonitor.CheckLinks(ctx, links, func(link models.Link, check models.LinkCheck) {
+			checks[link.ID] = check
+		})
+
+		items := make([]checkItem, 0, len(links))
+		down := 0
+		for _, link := range links {
+			check, ok := checks[link.ID]
+			if !ok {
+				continue
+			}
+			// Une destination refusée par la politique d'URL n'a pas été vérifiée
+			if !check.Accessible && check.ErrorClass != models.CheckErrorBlocked {
+				down++
+			}
+			items = append(items, checkItem{
+				ShortCode:      link.ShortCode,
+				LongURL:        link.LongURL,
+				Accessible:     check.Accessible,
+				StatusCode:     check.StatusCode,
+				Method:         check.Method,
+				LatencyMs:      check.LatencyMs,
+				ErrorClass:     check.ErrorClass,
+				FinalURL:       check.FinalURL,
+				CheckedAt:      check.CheckedAt,
+				RedirectChain:  check.RedirectChain,
+				HTTPSDowngrade: check.HTTPSDowngrade,
+				TLSExpiresAt:   check.TLSNotAfter,
+				ContentHash:    check.ContentHash,
+				PageTitle:      check.PageTitle,
+			})
+		}
+
+		if format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(map[string]interface{}{"checks": items, "inaccessible": down}); err != nil {
+				log.Fatalf("FATAL: Échec de l'écriture JSON: %v", err)
+			}
+		} else {
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "CODE\tÉTAT\tSTATUT\tMÉTHODE\tLATENCE\tERREUR\tREDIR.\tCERT. EXPIRE\tURL FINALE")
+			for _, it := range items {
+				state := "ACCESSIBLE"
+				switch {
+				case it.ErrorClass == models.CheckErrorBlocked:
+					state = "NON VÉRIFIÉ"
+				case !it.Accessible:
+					state = "INACCESSIBLE"
+				}
+				status := "-"
+				if it.StatusCode != 0 {
+					status = fmt.Sprint(it.StatusCode)
+				}
+				errorClass := "-"
+				if it.ErrorClass != "" {
+					errorClass = it.ErrorClass
+				}
+				certExpiry := "-"
+				if it.TLSExpiresAt != nil {
+					certExpiry = it.TLSExpiresAt.Format("2006-01-02")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%dms\t%s\t%d\t%s\t%s\n", it.ShortCode, state, status, it.Method,
+					it.LatencyMs, errorClass, len(it.RedirectChain), certExpiry, it.FinalURL)
+			}
+			tw.Flush()
+			for _, it := range items {
+				if it.HTTPSDowngrade {
+					fmt.Printf("⚠️  %s : la chaîne de redirections passe de HTTPS à HTTP.\n", it.ShortCode)
+				}
+			}
+			fmt.Printf("\n%d lien(s) vérifié(s), %d inaccessible(s).\n", len(items), down)
+			if len(items) < len(links) {
+				fmt.Printf("%d vérification(s) interrompue(s).\n", len(links)-len(items))
+			}
+		}
+
+		if down > 0 {
+			closeDB()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	CheckCmd.Flags().String("code", "", "Le code court du lien à vérifier")
+	CheckCmd.Flags().Bool("all", false, "Vérifier tous les liens")
+	CheckCmd.Flags().String("format", "table", "Format de sortie : table ou json")
+
+	cmd2.RootCmd.AddCommand(CheckCmd)
+}
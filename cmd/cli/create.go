@@ -1,14 +1,17 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/url" // Pour valider le format de l'URL
 	"os"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
 	"github.com/spf13/cobra"
 	// Driver SQLite pour GORM
 )
@@ -16,14 +19,34 @@ import (
 // longURLFlag stocke la valeur du flag --url
 var longURLFlag string
 
+// aliasFlag stocke la valeur du flag --alias (code court personnalisé, optionnel)
+var aliasFlag string
+
+// expiresAtFlag, expiresInFlag et maxClicksFlag définissent la durée de vie optionnelle du lien
+var (
+	expiresAtFlag string
+	expiresInFlag time.Duration
+	maxClicksFlag int
+)
+
+// fallbackURLFlag stocke la valeur du flag --fallback-url (URL de repli, optionnelle)
+var fallbackURLFlag string
+
 // CreateCmd représente la commande 'create'
 var CreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Crée une URL courte à partir d'une URL longue.",
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
-Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+Un code court personnalisé peut être choisi avec --alias (3 à 10 lettres ou chiffres).
+La durée de vie du lien peut être limitée par une date (--expires-at ou --expires-in)
+et/ou par un nombre maximal de clics (--max-clicks).
+Une URL de repli (--fallback-url) remplace la destination tant que le moniteur la juge inaccessible.
+
+Exemples:
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://go.dev" --alias="golang"
+  url-shortener create --url="https://go.dev" --expires-in=72h --max-clicks=100`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Valider que le flag --url a été fourni.
 		longURL, err := cmd.Flags().GetString("url")
@@ -38,6 +61,19 @@ Exemple:
 			log.Fatalf("FATAL: L'URL fournie n'est pas valide: %v", err)
 		}
 
+		// Calculer la date d'expiration éventuelle (--expires-at est prioritaire sur --expires-in)
+		var expiresAt *time.Time
+		if expiresAtFlag != "" {
+			t, err := time.Parse(time.RFC3339, expiresAtFlag)
+			if err != nil {
+				log.Fatalf("FATAL: --expires-at doit être au format RFC3339 (ex: 2025-12-31T23:59:59Z): %v", err)
+			}
+			expiresAt = &t
+		} else if expiresInFlag > 0 {
+			t := time.Now().Add(expiresInFlag)
+			expiresAt = &t
+		}
+
 		// Charger la configuration chargée globalement via cmd.cfg
 		cfg, err := cmd2.Cfg, nil
 		if err != nil {
@@ -59,14 +95,32 @@ Exemple:
 				log.Printf("✅ Connexion à la base de données fermée.")
 			}
 		}()
-		
+
 		// Initialiser les repositories et services nécessaires NewLinkRepository & NewLinkService
+		urlPolicy, err := urlpolicy.NewFromConfig(cfg.URLPolicy)
+		if err != nil {
+			log.Fatalf("FATAL: Configuration url_policy invalide: %v", err)
+		}
 		linkRepo := repository.NewLinkRepository(repository.DB())
-		linkService := services.NewLinkService(linkRepo)
+		linkService := services.NewLinkService(linkRepo).WithURLPolicy(urlPolicy)
 
 		// Appeler le LinkService et la fonction CreateLink pour créer le lien court.
-		link, err := linkService.CreateLink(longURL)
+		link, err := linkService.CreateLink(longURL, services.CreateLinkOptions{
+			Alias:       aliasFlag,
+			ExpiresAt:   expiresAt,
+			MaxClicks:   maxClicksFlag,
+			FallbackURL: fallbackURLFlag,
+		})
 		if err != nil {
+			if errors.Is(err, services.ErrAliasTaken) {
+				log.Fatalf("FATAL: L'alias '%s' est déjà utilisé par un autre lien.", aliasFlag)
+			}
+			if errors.Is(err, services.ErrInvalidFallbackURL) {
+				log.Fatalf("FATAL: L'URL de repli n'est pas valide: %v", err)
+			}
+			if errors.Is(err, services.ErrForbiddenDestination) {
+				log.Fatalf("FATAL: La destination désigne un réseau privé (voir url_policy): %v", err)
+			}
 			log.Fatalf("FATAL: Échec de la création du lien court: %v", err)
 			os.Exit(1)
 		}
@@ -75,6 +129,18 @@ Exemple:
 		fmt.Printf("URL courte créée avec succès:\n")
 		fmt.Printf("Code: %s\n", link.ShortCode)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks > 0 {
+			fmt.Printf("Budget de clics: %d\n", link.MaxClicks)
+		}
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de repli: %s\n", link.FallbackURL)
+		}
+		if link.PrivateDestination {
+			fmt.Println("⚠️  La destination désigne un réseau privé : le lien est signalé.")
+		}
 	},
 }
 
@@ -83,6 +149,11 @@ Exemple:
 func init() {
 	// Définir le flag --url pour la commande create.
 	CreateCmd.Flags().StringP("url", "u", "", "L'URL longue à raccourcir")
+	CreateCmd.Flags().StringVar(&aliasFlag, "alias", "", "Code court personnalisé (optionnel, 3 à 10 lettres ou chiffres)")
+	CreateCmd.Flags().StringVar(&expiresAtFlag, "expires-at", "", "Date d'expiration au format RFC3339 (optionnel)")
+	CreateCmd.Flags().DurationVar(&expiresInFlag, "expires-in", 0, "Durée de validité du lien, ex: 24h (optionnel)")
+	CreateCmd.Flags().IntVar(&maxClicksFlag, "max-clicks", 0, "Nombre maximal de redirections, 0 = illimité (optionnel)")
+	CreateCmd.Flags().StringVar(&fallbackURLFlag, "fallback-url", "", "URL de repli lorsque la destination est inaccessible (optionnel)")
 
 	// Marquer le flag comme requis
 	CreateCmd.MarkFlagRequired("url")
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"log"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+// openDatabase ouvre la base SQLite configurée pour une commande CLI.
+// Elle retourne la connexion GORM et une fonction à appeler (via defer) pour fermer
+// proprement la connexion à la fin de la commande.
+func openDatabase() (*gorm.DB, func()) {
+	gormDB := repository.ConnectDatabase()
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+	}
+
+	return gormDB, func() {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("WARN: Échec de la fermeture de la connexion à la base de données: %v", err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// DeleteCmd représente la commande 'delete'
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprime (ou restaure avec --restore) un lien court.",
+	Long: `Cette commande supprime logiquement un lien court : il n'est plus accessible,
+mais la ligne est conservée en base avec sa date de suppression et son code court reste réservé.
+Utilisez --restore pour annuler la suppression.
+
+Exemples:
+  url-shortener delete --code="xyz123"
+  url-shortener delete --code="xyz123" --restore`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shortCode, _ := cmd.Flags().GetString("code")
+		restore, _ := cmd.Flags().GetBool("restore")
+		if shortCode == "" {
+			log.Fatalf("FATAL: Le flag --code est requis.")
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := services.NewLinkService(repository.NewLinkRepository(db))
+
+		var err error
+		if restore {
+			_, err = linkService.RestoreLink(shortCode)
+		} else {
+			err = linkService.DeleteLink(shortCode)
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien correspondant pour le code court '%s'.\n", shortCode)
+				closeDB()
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de l'opération sur le lien: %v", err)
+		}
+
+		if restore {
+			fmt.Printf("Lien '%s' restauré.\n", shortCode)
+		} else {
+			fmt.Printf("Lien '%s' supprimé (restaurable avec --restore).\n", shortCode)
+		}
+	},
+}
+
+func init() {
+	DeleteCmd.Flags().String("code", "", "Le code court du lien à supprimer")
+	DeleteCmd.Flags().Bool("restore", false, "Restaure un lien supprimé au lieu de le supprimer")
+	DeleteCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(DeleteCmd)
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// DisableCmd représente la commande 'disable'
+var DisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Désactive (ou réactive avec --enable) la redirection d'un lien court.",
+	Long: `Cette commande désactive un lien court : il ne redirige plus et répond 410 Gone,
+mais il reste consultable et ses statistiques sont conservées.
+Utilisez --enable pour le réactiver. Un lien désactivé automatiquement par le moniteur
+(monitor.auto_disable_after_days) puis désactivé avec cette commande n'est plus réactivé automatiquement.
+
+Exemples:
+  url-shortener disable --code="xyz123"
+  url-shortener disable --code="xyz123" --enable`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shortCode, _ := cmd.Flags().GetString("code")
+		enable, _ := cmd.Flags().GetBool("enable")
+		if shortCode == "" {
+			log.Fatalf("FATAL: Le flag --code est requis.")
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := services.NewLinkService(repository.NewLinkRepository(db))
+		link, err := linkService.SetLinkDisabled(shortCode, !enable)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", shortCode)
+				closeDB()
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la modification du lien: %v", err)
+		}
+
+		if link.Disabled {
+			fmt.Printf("Lien '%s' désactivé.\n", link.ShortCode)
+		} else {
+			fmt.Printf("Lien '%s' réactivé.\n", link.ShortCode)
+		}
+	},
+}
+
+// printDisabled affiche la date et le motif de la désactivation d'un lien, s'il est désactivé.
+func printDisabled(link *models.Link) {
+	if !link.Disabled {
+		return
+	}
+	since := ""
+	if link.DisabledAt != nil {
+		since = " depuis le " + link.DisabledAt.Local().Format("2006-01-02 15:04")
+	}
+	if link.AutoDisabled {
+		fmt.Printf("Désactivé automatiquement%s : %s (réactivation automatique au rétablissement)\n", since, link.DisabledReason)
+		return
+	}
+	fmt.Printf("Désactivé%s\n", since)
+}
+
+func init() {
+	DisableCmd.Flags().String("code", "", "Le code court du lien à désactiver")
+	DisableCmd.Flags().Bool("enable", false, "Réactive le lien au lieu de le désactiver")
+	DisableCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(DisableCmd)
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// listItem est la représentation d'un lien utilisée pour les sorties JSON et CSV de 'list'.
+type listItem struct {
+	ShortCode   string     `json:"short_code"`
+	LongURL     string     `json:"long_url"`
+	Status      string     `json:"status"`
+	TotalClicks int64      `json:"total_clicks"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListCmd représente la commande 'list'
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les liens courts avec pagination, tri et filtres.",
+	Long: `Cette commande affiche une page de liens courts avec leur nombre de clics.
+Le curseur de la page suivante est affiché en fin de sortie (sur stderr) et se passe via --cursor.
+
+Exemples:
+  url-shortener list
+  url-shortener list --sort=clicks --limit=10
+  url-shortener list --search=github --status=active --format=csv
+  url-shortener list --created-after=2025-01-01 --format=json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		limit, _ := flags.GetInt("limit")
+		cursor, _ := flags.GetString("cursor")
+		sortBy, _ := flags.GetString("sort")
+		order, _ := flags.GetString("order")
+		search, _ := flags.GetString("search")
+		status, _ := flags.GetString("status")
+		createdAfter, _ := flags.GetString("created-after")
+		createdBefore, _ := flags.GetString("created-before")
+		format, _ := flags.GetString("format")
+
+		if order != "asc" && order != "desc" {
+			log.Fatalf("FATAL: --order doit valoir 'asc' ou 'desc'.")
+		}
+		if format != "table" && format != "json" && format != "csv" {
+			log.Fatalf("FATAL: --format doit valoir 'table', 'json' ou 'csv'.")
+		}
+
+		params := repository.LinkListParams{
+			Limit:     limit,
+			Cursor:    cursor,
+			SortBy:    sortBy,
+			Ascending: order == "asc",
+			Search:    search,
+			Status:    status,
+		}
+		if createdAfter != "" {
+			t, err := services.ParseTimeParam(createdAfter, time.Local)
+			if err != nil {
+				log.Fatalf("FATAL: --created-after: %v", err)
+			}
+			params.CreatedAfter = &t
+		}
+		if createdBefore != "" {
+			t, err := services.ParseTimeParam(createdBefore, time.Local)
+			if err != nil {
+				log.Fatalf("FATAL: --created-before: %v", err)
+			}
+			params.CreatedBefore = &t
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := services.NewLinkService(repository.NewLinkRepository(db))
+		page, err := linkService.ListLinks(params)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidListQuery) {
+				log.Fatalf("FATAL: Paramètres de listing invalides: %v", err)
+			}
+			log.Fatalf("FATAL: Échec de la récupération des liens: %v", err)
+		}
+
+		now := time.Now()
+		items := make([]listItem, 0, len(page.Items))
+		for _, it := range page.Items {
+			items = append(items, listItem{
+				ShortCode:   it.ShortCode,
+				LongURL:     it.LongURL,
+				Status:      it.Status(now),
+				TotalClicks: it.TotalClicks,
+				CreatedAt:   time.Unix(it.CreatedAt, 0),
+				ExpiresAt:   it.ExpiresAt,
+			})
+		}
+
+		switch format {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(map[string]interface{}{"links": items, "next_cursor": page.NextCursor}); err != nil {
+				log.Fatalf("FATAL: Échec de l'écriture JSON: %v", err)
+			}
+			return
+		case "csv":
+			w := csv.NewWriter(os.Stdout)
+			w.Write([]string{"short_code", "long_url", "status", "total_clicks", "created_at", "expires_at"})
+			for _, it := range items {
+				expires := ""
+				if it.ExpiresAt != nil {
+					expires = it.ExpiresAt.Format(time.RFC3339)
+				}
+				w.Write([]string{it.ShortCode, it.LongURL, it.Status, strconv.FormatInt(it.TotalClicks, 10),
+					it.CreatedAt.Format(time.RFC3339), expires})
+			}
+			w.Flush()
+			if err := w.Error(); err != nil {
+				log.Fatalf("FATAL: Échec de l'écriture CSV: %v", err)
+			}
+		default:
+			if len(items) == 0 {
+				fmt.Println("Aucun lien trouvé.")
+				return
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "CODE\tCLICS\tSTATUT\tCRÉÉ LE\tURL LONGUE")
+			for _, it := range items {
+				fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", it.ShortCode, it.TotalClicks, it.Status,
+					it.CreatedAt.Format("2006-01-02 15:04"), it.LongURL)
+			}
+			tw.Flush()
+		}
+
+		if page.NextCursor != "" {
+			fmt.Fprintf(os.Stderr, "Page suivante: --cursor=%s\n", page.NextCursor)
+		}
+	},
+}
+
+func init() {
+	ListCmd.Flags().Int("limit", 20, "Nombre de liens par page (max 100)")
+	ListCmd.Flags().String("cursor", "", "Curseur de la page à afficher (fourni par la page précédente)")
+	ListCmd.Flags().String("sort", repository.LinkSortCreatedAt, "Critère de tri : created_at ou clicks")
+	ListCmd.Flags().String("order", "desc", "Ordre de tri : asc ou desc")
+	ListCmd.Flags().String("search", "", "Ne garder que les liens dont l'URL longue contient cette chaîne")
+	ListCmd.Flags().String("status", "", "Filtrer par statut : active, disabled, expired ou deleted")
+	ListCmd.Flags().String("created-after", "", "Créés à partir de cette date (RFC3339 ou YYYY-MM-DD)")
+	ListCmd.Flags().String("created-before", "", "Créés avant cette date (RFC3339 ou YYYY-MM-DD)")
+	ListCmd.Flags().String("format", "table", "Format de sortie : table, json ou csv")
+
+	cmd2.RootCmd.AddCommand(ListCmd)
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// MaintenanceCmd représente la commande 'maintenance' et ses sous-commandes add, list et delete.
+var MaintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Gère les fenêtres de maintenance du moniteur.",
+	Long: `Pendant une fenêtre de maintenance, le moniteur continue de vérifier et d'historiser
+les destinations, mais n'envoie aucune notification. Une fenêtre concerne un lien (--code)
+ou tous les liens. Un changement d'état survenu pendant la maintenance est notifié à la
+première vérification qui suit, s'il persiste.
+
+Exemples:
+  url-shortener maintenance add --code="xyz123" --duration=2h --reason="Migration du serveur"
+  url-shortener maintenance add --start="2026-11-01T22:00:00Z" --end="2026-11-02T02:00:00Z"
+  url-shortener maintenance list
+  url-shortener maintenance delete --id=3`,
+}
+
+// maintenanceAddCmd représente la commande 'maintenance add'
+var maintenanceAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Déclare une fenêtre de maintenance, pour un lien ou pour tous les liens.",
+	Run: func(cmd *cobra.Command, args []string) {
+		shortCode, _ := cmd.Flags().GetString("code")
+		startFlag, _ := cmd.Flags().GetString("start")
+		endFlag, _ := cmd.Flags().GetString("end")
+		duration, _ := cmd.Flags().GetDuration("duration")
+		reason, _ := cmd.Flags().GetString("reason")
+		if (endFlag == "") == (duration <= 0) {
+			log.Fatalf("FATAL: Indiquez soit --end, soit --duration.")
+		}
+
+		startsAt := time.Now()
+		if startFlag != "" {
+			t, err := time.Parse(time.RFC3339, startFlag)
+			if err != nil {
+				log.Fatalf("FATAL: --start doit être au format RFC3339 (ex: 2025-12-31T22:00:00Z): %v", err)
+			}
+			startsAt = t
+		}
+		endsAt := startsAt.Add(duration)
+		if endFlag != "" {
+			t, err := time.Parse(time.RFC3339, endFlag)
+			if err != nil {
+				log.Fatalf("FATAL: --end doit être au format RFC3339 (ex: 2025-12-31T23:59:59Z): %v", err)
+			}
+			endsAt = t
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		maintenanceService := services.NewMaintenanceService(repository.NewMaintenanceWindowRepository(db), repository.NewLinkRepository(db))
+		window, err := maintenanceService.CreateWindow(shortCode, startsAt, endsAt, reason)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", shortCode)
+				closeDB()
+				os.Exit(1)
+			}
+			if errors.Is(err, services.ErrInvalidMaintenanceWindow) {
+				log.Fatalf("FATAL: Fenêtre de maintenance invalide: %v", err)
+			}
+			log.Fatalf("FATAL: Échec de l'enregistrement de la fenêtre de maintenance: %v", err)
+		}
+
+		scope := "tous les liens"
+		if shortCode != "" {
+			scope = "le lien " + shortCode
+		}
+		fmt.Printf("Fenêtre de maintenance %d enregistrée pour %s, du %s au %s.\n", window.ID, scope,
+			window.StartsAt.Local().Format(time.RFC3339), window.EndsAt.Local().Format(time.RFC3339))
+	},
+}
+
+// maintenanceListCmd représente la commande 'maintenance list'
+var maintenanceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les fenêtres de maintenance en cours et à venir (toutes avec --all).",
+	Run: func(cmd *cobra.Command, args []string) {
+		all, _ := cmd.Flags().GetBool("all")
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		maintenanceService := services.NewMaintenanceService(repository.NewMaintenanceWindowRepository(db), repository.NewLinkRepository(db))
+		windows, err := maintenanceService.ListWindows(all)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de la récupération des fenêtres de maintenance: %v", err)
+		}
+		if len(windows) == 0 {
+			fmt.Println("Aucune fenêtre de maintenance.")
+			return
+		}
+
+		now := time.Now()
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tLIEN\tDÉBUT\tFIN\tÉTAT\tMOTIF")
+		for _, window := range windows {
+			scope := "(tous)"
+			if window.Link != nil {
+				scope = window.Link.ShortCode
+			}
+			state := "à venir"
+			switch {
+			case window.IsActive(now):
+				state = "en cours"
+			case !window.EndsAt.After(now):
+				state = "terminée"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", window.ID, scope, window.StartsAt.Local().Format("2006-01-02 15:04"),
+				window.EndsAt.Local().Format("2006-01-02 15:04"), state, window.Reason)
+		}
+		tw.Flush()
+	},
+}
+
+// maintenanceDeleteCmd représente la commande 'maintenance delete'
+var maintenanceDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Supprime une fenêtre de maintenance (la termine si elle est en cours).",
+	Run: func(cmd *cobra.Command, args []string) {
+		id, _ := cmd.Flags().GetUint("id")
+		if id == 0 {
+			log.Fatalf("FATAL: Le flag --id est requis.")
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		maintenanceService := services.NewMaintenanceService(repository.NewMaintenanceWindowRepository(db), repository.NewLinkRepository(db))
+		if err := maintenanceService.DeleteWindow(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucune fenêtre de maintenance %d.\n", id)
+				closeDB()
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la suppression de la fenêtre de maintenance: %v", err)
+		}
+		fmt.Printf("Fenêtre de maintenance %d supprimée.\n", id)
+	},
+}
+
+func init() {
+	maintenanceAddCmd.Flags().String("code", "", "Le code court du lien concerné (vide = tous les liens)")
+	maintenanceAddCmd.Flags().String("start", "", "Début de la fenêtre au format RFC3339 (défaut: maintenant)")
+	maintenanceAddCmd.Flags().String("end", "", "Fin de la fenêtre au format RFC3339")
+	maintenanceAddCmd.Flags().Duration("duration", 0, "Durée de la fenêtre (ex: 2h, 30m), alternative à --end")
+	maintenanceAddCmd.Flags().String("reason", "", "Motif de la maintenance")
+	maintenanceListCmd.Flags().Bool("all", false, "Inclure les fenêtres terminées")
+	maintenanceDeleteCmd.Flags().Uint("id", 0, "Identifiant de la fenêtre à supprimer")
+	maintenanceDeleteCmd.MarkFlagRequired("id")
+
+	MaintenanceCmd.AddCommand(maintenanceAddCmd, maintenanceListCmd, maintenanceDeleteCmd)
+	cmd2.RootCmd.AddCommand(MaintenanceCmd)
+}
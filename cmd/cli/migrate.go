@@ -20,8 +20,8 @@ var MigrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Exécute les migrations de la base de données pour créer ou mettre à jour les tables.",
 	Long: `Cette commande se connecte à la base de données configurée (SQLite)
-et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
-basées sur les modèles Go.`,
+et exécute les migrations automatiques de GORM pour créer les tables 'links', 'clicks',
+'click_daily_aggregates', 'link_checks' et 'maintenance_windows' basées sur les modèles Go.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Charger la configuration : priorité au flag --db, sinon config, sinon par défaut
 		dbPath := dbPathFlag
@@ -54,7 +54,8 @@ basées sur les modèles Go.`,
 		}()
 
 		// Exécuter les migrations automatiques de GORM pour tous les modèles
-		if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
+		if err := db.AutoMigrate(&models.Link{}, &models.Click{}, &models.ClickDailyAggregate{}, &models.LinkCheck{},
+			&models.MaintenanceWindow{}); err != nil {
 			log.Fatalf("✗ FATAL: échec des migrations : %v", err)
 		}
 
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+)
+
+// PurgeClicksCmd représente la commande 'purge-clicks'
+var PurgeClicksCmd = &cobra.Command{
+	Use:   "purge-clicks",
+	Short: "Supprime ou agrège les clics bruts plus anciens que la durée de rétention.",
+	Long: `Cette commande applique immédiatement la politique de rétention des clics (analytics.privacy) :
+les clics bruts plus anciens que --older-than-days jours sont supprimés (mode delete) ou
+résumés en compteurs journaliers avant suppression (mode aggregate).
+Par défaut, la durée et le mode sont ceux de la configuration.
+
+Exemples:
+  url-shortener purge-clicks --older-than-days=90
+  url-shortener purge-clicks --older-than-days=30 --mode=aggregate --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := cmd2.Cfg
+		days, _ := cmd.Flags().GetInt("older-than-days")
+		mode, _ := cmd.Flags().GetString("mode")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if days == 0 && cfg != nil {
+			days = cfg.Analytics.Privacy.RetentionDays
+		}
+		if mode == "" && cfg != nil {
+			mode = cfg.Analytics.Privacy.RetentionMode
+		}
+		if days <= 0 {
+			log.Fatalf("FATAL: Aucune durée de rétention : utilisez --older-than-days ou analytics.privacy.retention_days.")
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		clickService := services.NewClickService(repository.NewClickRepository(db))
+		count, cutoff, err := clickService.PurgeClicks(days, mode, dryRun)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidRetention) {
+				log.Fatalf("FATAL: Paramètres de purge invalides: %v", err)
+			}
+			log.Fatalf("FATAL: Échec de la purge des clics: %v", err)
+		}
+
+		if dryRun {
+			fmt.Printf("%d clic(s) antérieur(s) au %s seraient traités (mode %s).\n", count, cutoff.Format(time.DateOnly), mode)
+			return
+		}
+		fmt.Printf("✓ %d clic(s) antérieur(s) au %s traité(s) (mode %s).\n", count, cutoff.Format(time.DateOnly), mode)
+	},
+}
+
+func init() {
+	PurgeClicksCmd.Flags().Int("older-than-days", 0, "Purge les clics plus anciens que ce nombre de jours (défaut: analytics.privacy.retention_days)")
+	PurgeClicksCmd.Flags().String("mode", "", "delete ou aggregate (défaut: analytics.privacy.retention_mode)")
+	PurgeClicksCmd.Flags().Bool("dry-run", false, "Affiche le nombre de clics concernés sans rien modifier")
+
+	cmd2.RootCmd.AddCommand(PurgeClicksCmd)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"text/tabwriter"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/repository"
@@ -16,6 +18,14 @@ import (
 
 var shortCodeFlag string
 
+// Flags du mode série temporelle de 'stats'
+var (
+	statsFromFlag     string
+	statsToFlag       string
+	statsIntervalFlag string
+	statsTZFlag       string
+	statsBotsFlag     bool
+)
 
 // StatsCmd représente la commande 'stats'
 var StatsCmd = &cobra.Command{
@@ -24,8 +34,12 @@ var StatsCmd = &cobra.Command{
 	Long: `Cette commande permet de récupérer et d'afficher le nombre total de clics
 pour une URL courte spécifique en utilisant son code.
 
-Exemple:
-  url-shortener stats --code="xyz123"`,
+Avec --interval (hour, day ou week), la commande affiche aussi le nombre de clics par période
+entre --from et --to (RFC3339 ou YYYY-MM-DD, par défaut les 7 derniers jours), dans le fuseau --tz.
+
+Exemples:
+  url-shortener stats --code="xyz123"
+  url-shortener stats --code="xyz123" --interval=day --from=2025-06-01 --to=2025-07-01 --tz=Europe/Paris`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if shortCodeFlag == "" {
 			fmt.Println("Erreur: le flag --code est requis.")
@@ -42,7 +56,6 @@ Exemple:
 			log.Fatalf("FATAL: Échec de la connexion à la base de données: %v", err)
 		}
 
-
 		sqlDB, err := db.DB()
 		if err != nil {
 			log.Fatalf("FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
@@ -57,7 +70,7 @@ Exemple:
 		linkRepo := repository.NewLinkRepository(db)
 		linkService := services.NewLinkService(linkRepo)
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCodeFlag)
+		link, counts, err := linkService.GetLinkStats(shortCodeFlag)
 		if err != nil {
 			if err == gorm.ErrRecordNotFound {
 				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", shortCodeFlag)
@@ -69,13 +82,94 @@ Exemple:
 
 		fmt.Printf("Statistiques pour le code court: %s\n", link.ShortCode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
-		fmt.Printf("Total de clics: %d\n", totalClicks)
+		printDisabled(link)
+		if link.PrivateDestination {
+			fmt.Println("Destination: réseau privé (signalée par url_policy)")
+		}
+		fmt.Printf("Total de clics: %d\n", counts.Total)
+		fmt.Printf("Clics humains: %d (robots: %d)\n", counts.Human, counts.Bots())
+
+		clickService := services.NewClickService(repository.NewClickRepository(db))
+		uniqueVisitors, err := clickService.GetUniqueVisitors(link.ID, statsBotsFlag)
+		if err != nil {
+			log.Fatalf("FATAL: Échec du comptage des visiteurs uniques: %v", err)
+		}
+		fmt.Printf("Visiteurs uniques: %d (un visiteur revenant plusieurs jours est compté une fois par jour)\n", uniqueVisitors)
+		aggregatedVisitorDays, err := clickService.GetAggregatedVisitorDays(link.ID, statsBotsFlag)
+		if err != nil {
+			log.Fatalf("FATAL: Échec du comptage des visiteurs agrégés: %v", err)
+		}
+		if aggregatedVisitorDays > 0 {
+			fmt.Printf("Visiteurs-jours agrégés: %d (somme des visiteurs uniques de chaque jour purgé)\n", aggregatedVisitorDays)
+		}
+
+		topReferrers, err := clickService.GetClickBreakdown(link.ID, repository.DimensionReferrer, 5, statsBotsFlag)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de la récupération des référents: %v", err)
+		}
+		if len(topReferrers) > 0 {
+			fmt.Printf("\nPrincipaux référents:\n")
+			printBreakdown(topReferrers)
+		}
+
+		if statsIntervalFlag == "" {
+			return
+		}
+
+		loc, err := time.LoadLocation(statsTZFlag)
+		if err != nil {
+			log.Fatalf("FATAL: Fuseau horaire inconnu '%s': %v", statsTZFlag, err)
+		}
+		to := time.Now()
+		if statsToFlag != "" {
+			if to, err = services.ParseTimeParam(statsToFlag, loc); err != nil {
+				log.Fatalf("FATAL: --to: %v", err)
+			}
+		}
+		from := to.AddDate(0, 0, -7)
+		if statsFromFlag != "" {
+			if from, err = services.ParseTimeParam(statsFromFlag, loc); err != nil {
+				log.Fatalf("FATAL: --from: %v", err)
+			}
+		}
+
+		buckets, err := clickService.GetClickTimeSeries(link.ID, from, to, statsIntervalFlag, loc, statsBotsFlag)
+		if err != nil {
+			log.Fatalf("FATAL: Échec de la récupération de la série temporelle: %v", err)
+		}
+
+		layout := "2006-01-02"
+		if statsIntervalFlag == services.IntervalHour {
+			layout = "2006-01-02 15:04"
+		}
+		fmt.Printf("\nClics par %s (%s):\n", statsIntervalFlag, loc)
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PÉRIODE\tCLICS\tVISITEURS UNIQUES")
+		for _, b := range buckets {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", b.Start.Format(layout), b.Clicks, b.UniqueVisitors)
+		}
+		tw.Flush()
 	},
 }
 
+// printBreakdown affiche une répartition de clics sous forme de tableau aligné.
+func printBreakdown(entries []repository.BreakdownEntry) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range entries {
+		fmt.Fprintf(tw, "  %s\t%d\n", e.Value, e.Clicks)
+	}
+	tw.Flush()
+}
+
 func init() {
 	StatsCmd.Flags().StringVar(&shortCodeFlag, "code", "", "Le code court de l'URL pour laquelle récupérer les statistiques.")
 
+	StatsCmd.Flags().StringVar(&statsIntervalFlag, "interval", "", "Affiche les clics par période : hour, day ou week")
+	StatsCmd.Flags().StringVar(&statsFromFlag, "from", "", "Début de la période (RFC3339 ou YYYY-MM-DD)")
+	StatsCmd.Flags().StringVar(&statsToFlag, "to", "", "Fin de la période, exclue (RFC3339 ou YYYY-MM-DD)")
+	StatsCmd.Flags().BoolVar(&statsBotsFlag, "include-bots", false, "Compte les clics de robots dans les référents et la série temporelle")
+	StatsCmd.Flags().StringVar(&statsTZFlag, "tz", "Local", "Fuseau horaire IANA des périodes (ex: Europe/Paris)")
+
 	StatsCmd.MarkFlagRequired("code")
 
 	cmd2.RootCmd.AddCommand(StatsCmd)
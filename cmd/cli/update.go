@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// UpdateCmd représente la commande 'update'
+var UpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Modifie l'URL de destination, l'URL de repli ou les réglages de surveillance d'un lien court.",
+	Long: `Cette commande remplace l'URL longue vers laquelle redirige un lien court existant,
+son URL de repli et/ou ses réglages de surveillance. Le code court et les statistiques de clics sont conservés.
+Une valeur vide (ou un seuil à 0) rétablit le réglage global de la configuration.
+
+Exemples:
+  url-shortener update --code="xyz123" --url="https://go.dev/doc"
+  url-shortener update --code="xyz123" --fallback-url="https://web.archive.org/web/https://go.dev/doc"
+  url-shortener update --code="xyz123" --monitor-accepted-status="2xx,401" --monitor-failure-threshold=3
+  url-shortener update --code="xyz123" --monitor-get-fallback=false --monitor-user-agent="MonBot/2.0"
+  url-shortener update --code="xyz123" --monitor-content-fingerprint
+  url-shortener update --code="xyz123" --monitor-enabled=false
+  url-shortener update --code="xyz123" --monitor-interval=60 --monitor-accepted-status="2xx,401"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shortCode, _ := cmd.Flags().GetString("code")
+		if shortCode == "" {
+			log.Fatalf("FATAL: Le flag --code est requis.")
+		}
+
+		var opts services.UpdateLinkOptions
+		if cmd.Flags().Changed("url") {
+			longURL, _ := cmd.Flags().GetString("url")
+			if _, err := url.ParseRequestURI(longURL); err != nil {
+				log.Fatalf("FATAL: L'URL fournie n'est pas valide: %v", err)
+			}
+			opts.LongURL = &longURL
+		}
+		if cmd.Flags().Changed("fallback-url") {
+			fallbackURL, _ := cmd.Flags().GetString("fallback-url")
+			opts.FallbackURL = &fallbackURL
+		}
+
+		monitorOpts := services.MonitorSettingsUpdate{}
+		monitorOpts.Reset, _ = cmd.Flags().GetBool("monitor-reset")
+		if cmd.Flags().Changed("monitor-enabled") {
+			enabled, _ := cmd.Flags().GetBool("monitor-enabled")
+			monitorOpts.Enabled = &enabled
+		}
+		if cmd.Flags().Changed("monitor-interval") {
+			interval, _ := cmd.Flags().GetInt("monitor-interval")
+			monitorOpts.IntervalMinutes = &interval
+		}
+		if cmd.Flags().Changed("monitor-accepted-status") {
+			codes, _ := cmd.Flags().GetString("monitor-accepted-status")
+			monitorOpts.AcceptedStatusCodes = &codes
+		}
+		if cmd.Flags().Changed("monitor-failure-threshold") {
+			threshold, _ := cmd.Flags().GetInt("monitor-failure-threshold")
+			monitorOpts.FailureThreshold = &threshold
+		}
+		if cmd.Flags().Changed("monitor-user-agent") {
+			userAgent, _ := cmd.Flags().GetString("monitor-user-agent")
+			monitorOpts.UserAgent = &userAgent
+		}
+		if cmd.Flags().Changed("monitor-get-fallback") {
+			getFallback, _ := cmd.Flags().GetBool("monitor-get-fallback")
+			monitorOpts.GetFallback = &getFallback
+		}
+		if cmd.Flags().Changed("monitor-content-fingerprint") {
+			contentFingerprint, _ := cmd.Flags().GetBool("monitor-content-fingerprint")
+			monitorOpts.ContentFingerprint = &contentFingerprint
+		}
+		if monitorOpts != (services.MonitorSettingsUpdate{}) {
+			opts.Monitor = &monitorOpts
+		}
+
+		urlPolicy, err := urlpolicy.NewFromConfig(cmd2.Cfg.URLPolicy)
+		if err != nil {
+			log.Fatalf("FATAL: Configuration url_policy invalide: %v", err)
+		}
+
+		db, closeDB := openDatabase()
+		defer closeDB()
+
+		linkService := services.NewLinkService(repository.NewLinkRepository(db)).WithURLPolicy(urlPolicy)
+		link, err := linkService.UpdateLink(shortCode, opts)
+		if err != nil {
+			if errors.Is(err, services.ErrNothingToUpdate) {
+				log.Fatalf("FATAL: Aucune modification demandée (--url, --fallback-url ou --monitor-*).")
+			}
+			if errors.Is(err, services.ErrInvalidFallbackURL) {
+				log.Fatalf("FATAL: L'URL de repli n'est pas valide: %v", err)
+			}
+			if errors.Is(err, services.ErrInvalidMonitorSettings) {
+				log.Fatalf("FATAL: Réglage de surveillance invalide: %v", err)
+			}
+			if errors.Is(err, services.ErrForbiddenDestination) {
+				log.Fatalf("FATAL: La destination désigne un réseau privé (voir url_policy): %v", err)
+			}
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Printf("Erreur: Aucun lien trouvé pour le code court '%s'.\n", shortCode)
+				closeDB()
+				os.Exit(1)
+			}
+			log.Fatalf("FATAL: Échec de la mise à jour du lien: %v", err)
+		}
+
+		fmt.Printf("Lien mis à jour avec succès:\n")
+		fmt.Printf("Code: %s\n", link.ShortCode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+		if link.PrivateDestination {
+			fmt.Println("⚠️  La destination désigne un réseau privé : le lien est signalé.")
+		}
+		if link.FallbackURL != "" {
+			fmt.Printf("URL de repli: %s\n", link.FallbackURL)
+		}
+		printMonitorSettings(link.Monitor)
+	},
+}
+
+// printMonitorSettings affiche les réglages de surveillance propres au lien.
+func printMonitorSettings(settings models.LinkMonitorSettings) {
+	if settings.IsZero() {
+		fmt.Println("Surveillance: réglages globaux")
+		return
+	}
+	fmt.Println("Surveillance:")
+	if !settings.IsEnabled() {
+		fmt.Println("  Désactivée")
+	}
+	if settings.IntervalMinutes != nil {
+		fmt.Printf("  Intervalle: %d min\n", *settings.IntervalMinutes)
+	}
+	if settings.AcceptedStatusCodes != "" {
+		fmt.Printf("  Statuts acceptés: %s\n", settings.AcceptedStatusCodes)
+	}
+	if settings.FailureThreshold != nil {
+		fmt.Printf("  Seuil d'échecs consécutifs: %d\n", *settings.FailureThreshold)
+	}
+	if settings.UserAgent != "" {
+		fmt.Printf("  User-Agent: %s\n", settings.UserAgent)
+	}
+	if settings.GetFallback != nil {
+		fmt.Printf("  Repli en GET: %t\n", *settings.GetFallback)
+	}
+	if settings.ContentFingerprint != nil {
+		fmt.Printf("  Empreinte du contenu: %t\n", *settings.ContentFingerprint)
+	}
+}
+
+func init() {
+	UpdateCmd.Flags().String("code", "", "Le code court du lien à modifier")
+	UpdateCmd.Flags().StringP("url", "u", "", "La nouvelle URL longue")
+	UpdateCmd.Flags().String("fallback-url", "", "URL de repli lorsque la destination est inaccessible (vide = repli global)")
+	UpdateCmd.Flags().Bool("monitor-reset", false, "Rétablit tous les réglages de surveillance globaux")
+	UpdateCmd.Flags().Bool("monitor-enabled", true, "Surveiller le lien (false = exclu du moniteur)")
+	UpdateCmd.Flags().Int("monitor-interval", 0, "Intervalle en minutes entre deux vérifications du lien (0 = global)")
+	UpdateCmd.Flags().String("monitor-accepted-status", "", "Statuts HTTP considérés comme accessibles (ex: \"200-299,401\")")
+	UpdateCmd.Flags().Int("monitor-failure-threshold", 0, "Échecs consécutifs avant de déclarer le lien inaccessible")
+	UpdateCmd.Flags().String("monitor-user-agent", "", "En-tête User-Agent des vérifications")
+	UpdateCmd.Flags().Bool("monitor-get-fallback", true, "Réessayer en GET lorsque HEAD est refusé")
+	UpdateCmd.Flags().Bool("monitor-content-fingerprint", true, "Suivre l'empreinte du contenu pour détecter les changements de page")
+	UpdateCmd.MarkFlagRequired("code")
+
+	cmd2.RootCmd.AddCommand(UpdateCmd)
+}
@@ -8,15 +8,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/analytics"
 	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/notify"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
 	"github.com/axellelanca/urlshortener/internal/workers"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
@@ -40,36 +46,116 @@ var RunServerCmd = &cobra.Command{
 		if err != nil {
 			log.Fatalf("Failed to open SQLite database: %v", err)
 		}
-		if err := db.AutoMigrate(&models.Link{}, &models.Click{}); err != nil {
+		if err := db.AutoMigrate(&models.Link{}, &models.Click{}, &models.ClickDailyAggregate{}, &models.LinkCheck{},
+			&models.MaintenanceWindow{}); err != nil {
 			log.Fatalf("AutoMigrate error: %v", err)
 		}
 
 		// Repositories
 		linkRepo := repository.NewLinkRepository(db)
 		clickRepo := repository.NewClickRepository(db)
+		checkRepo := repository.NewLinkCheckRepository(db)
+		windowRepo := repository.NewMaintenanceWindowRepository(db)
 		log.Println("Repositories initialized.")
 
 		// Services
-		linkService := services.NewLinkService(linkRepo)
+		urlPolicy, err := urlpolicy.NewFromConfig(cfg.URLPolicy)
+		if err != nil {
+			log.Fatalf("Invalid url_policy configuration: %v", err)
+		}
+		linkService := services.NewLinkService(linkRepo).WithURLPolicy(urlPolicy)
 		clickService := services.NewClickService(clickRepo)
+		healthService := services.NewLinkHealthService(checkRepo)
+		maintenanceService := services.NewMaintenanceService(windowRepo, linkRepo)
 		log.Println("Domain services initialized.")
 
+		// Background tasks (monitor, retention) stop when this context is cancelled
+		bgCtx, cancelBackground := context.WithCancel(context.Background())
+		defer cancelBackground()
+		var background sync.WaitGroup
+
 		// Click events channel + workers (use models.ClickEvent)
 		clickEvents := make(chan models.ClickEvent, cfg.Analytics.BufferSize)
 		api.ClickEventsChannel = clickEvents
-		workers.StartClickWorkers(cfg.Analytics.WorkerCount, clickEvents, clickRepo)
+		metrics.ClickQueueDepth.SetFunc(func() float64 { return float64(len(clickEvents)) })
+		api.ExpiredLinkFallbackURL = cfg.Links.ExpiredRedirectURL
+		api.DownLinkFallbackURL = cfg.Links.DownFallbackURL
+		api.DownLinkStatusPage = cfg.Links.DownStatusPage
+		botClassifier, err := analytics.NewBotClassifier(cfg.Analytics.BotIPRanges)
+		if err != nil {
+			log.Fatalf("Invalid analytics.bot_ip_ranges: %v", err)
+		}
+		api.BotClassifier = botClassifier
+		visitorHasher := analytics.NewVisitorHasher()
+		privacy := cfg.Analytics.Privacy
+		ipAnonymizer, err := analytics.NewIPAnonymizer(privacy.IPMode, privacy.IPv4PrefixLength, privacy.IPv6PrefixLength)
+		if err != nil {
+			log.Fatalf("Invalid analytics.privacy configuration: %v", err)
+		}
+		if privacy.RetentionDays > 0 && privacy.PurgeIntervalHours <= 0 {
+			log.Fatalf("Invalid analytics.privacy.purge_interval_hours: %d (must be positive when retention_days is set)", privacy.PurgeIntervalHours)
+		}
+		enricher := analytics.NewEnricher(botClassifier, visitorHasher, ipAnonymizer)
+		batch := workers.BatchOptions{
+			Size:          cfg.Analytics.BatchSize,
+			FlushInterval: time.Duration(cfg.Analytics.BatchFlushIntervalMs) * time.Millisecond,
+		}
+		clickWorkers := workers.StartClickWorkers(cfg.Analytics.WorkerCount, batch, clickEvents, clickRepo, enricher)
 		log.Printf("Click event channel initialized with buffer %d. Started %d click worker(s).",
 			cfg.Analytics.BufferSize, cfg.Analytics.WorkerCount)
 
+		// Durable click spool (optional): replays events left over from the previous run
+		var clickSpool *spool.Spool
+		if spoolCfg := cfg.Analytics.Spool; spoolCfg.Enabled {
+			clickSpool, err = spool.Open(spool.Options{
+				Dir:             spoolCfg.Dir,
+				MaxSegmentBytes: int64(spoolCfg.MaxSegmentMB) << 20,
+				MaxTotalBytes:   int64(spoolCfg.MaxTotalMB) << 20,
+				SyncWrites:      spoolCfg.SyncWrites,
+			})
+			if err != nil {
+				log.Fatalf("Failed to open click spool: %v", err)
+			}
+			clickSpool.Start(clickEvents)
+			api.ClickSpool = clickSpool
+			api.ClickEnricher = enricher
+			metrics.ClickSpoolBacklogBytes.SetFunc(func() float64 { return float64(clickSpool.Backlog()) })
+			log.Printf("Durable click spool enabled in %s.", spoolCfg.Dir)
+		}
+
+		// Click retention policy
+		if privacy.RetentionDays > 0 {
+			purgeInterval := time.Duration(privacy.PurgeIntervalHours) * time.Hour
+			background.Add(1)
+			go func() {
+				defer background.Done()
+				workers.StartRetentionWorker(bgCtx, clickService, privacy.RetentionDays, privacy.RetentionMode, purgeInterval)
+			}()
+		}
+
 		// URL monitor
-		monitorInterval := time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepo, monitorInterval)
-		go urlMonitor.Start()
-		log.Printf("URL monitor started with interval %v.", monitorInterval)
+		monitorOpts, err := monitor.OptionsFromConfig(cfg)
+		if err != nil {
+			log.Fatalf("Invalid monitor configuration: %v", err)
+		}
+		monitorOpts.Notifier, err = notify.NewDispatcherFromConfig(cfg.Monitor.Notifiers)
+		if err != nil {
+			log.Fatalf("Invalid monitor notifiers configuration: %v", err)
+		}
+		notifier := monitorOpts.Notifier
+		urlMonitor := monitor.NewUrlMonitor(linkRepo, checkRepo, windowRepo, monitorOpts)
+		// Redirects consult the monitor to avoid sending users to unreachable destinations
+		api.LinkStates = urlMonitor
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			urlMonitor.Start(bgCtx)
+		}()
+		log.Printf("URL monitor started with interval %v.", monitorOpts.Interval)
 
 		// Router and routes
 		router := gin.Default()
-		api.RegisterRoutes(router, linkService, clickService, clickEvents)
+		api.RegisterRoutes(router, linkService, clickService, healthService, maintenanceService, urlMonitor, clickEvents)
 		log.Println("API routes configured.")
 
 		// HTTP server
@@ -93,14 +179,63 @@ var RunServerCmd = &cobra.Command{
 		<-quit
 		log.Println("Shutdown signal received. Stopping server...")
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
+
+		// 1. Stop the monitor and retention worker (in-flight checks are aborted)
+		cancelBackground()
+
+		// 2. Stop accepting requests and wait for in-flight redirects
+		httpStopped := true
 		if err := srv.Shutdown(ctx); err != nil {
 			log.Printf("Server shutdown error: %v", err)
+			httpStopped = false
 		}
 
-		log.Println("Shutting down... giving workers time to finish.")
-		time.Sleep(5 * time.Second)
+		// 3. Drain the click events: no more producers, then close the channel so that
+		// workers flush what is buffered and exit.
+		if clickSpool != nil {
+			clickSpool.StopReading()
+		}
+		workersStopped := false
+		if httpStopped {
+			close(clickEvents)
+			drained := make(chan struct{})
+			go func() {
+				clickWorkers.Wait()
+				close(drained)
+			}()
+			select {
+			case <-drained:
+				workersStopped = true
+				log.Println("Click workers drained.")
+			case <-ctx.Done():
+				log.Printf("Shutdown deadline exceeded: %d buffered click event(s) were not saved.", len(clickEvents))
+			}
+		} else {
+			// Handlers may still be running and sending on the channel: it cannot be closed safely.
+			log.Printf("HTTP server did not stop in time: %d buffered click event(s) were not saved.", len(clickEvents))
+		}
+		if clickSpool != nil {
+			// Unacknowledged events are replayed on next start
+			if err := clickSpool.Close(); err != nil {
+				log.Printf("Click spool close error: %v", err)
+			}
+		}
+
+		// 4. Wait for background tasks and pending notifications, then release the database.
+		// Click workers that are still running keep it open: closing it would make their
+		// in-flight inserts fail. The process exit releases it instead.
+		background.Wait()
+		notifier.Wait(ctx)
+		if !workersStopped {
+			log.Println("Click workers still running: database left open until exit.")
+			return
+		}
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
 		log.Println("Server stopped cleanly.")
 	},
 }
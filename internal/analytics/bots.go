@@ -0,0 +1,66 @@
+package analytics
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// headlessMarkers sont des fragments de User-Agent propres aux navigateurs automatisés.
+var headlessMarkers = []string{"headlesschrome", "phantomjs", "puppeteer", "playwright", "selenium", "electron", "lighthouse"}
+
+// BotClassifier détermine si un clic provient d'un robot (crawler, aperçu de lien,
+// sonde de disponibilité, navigateur automatisé...).
+type BotClassifier struct {
+	ipRanges []*net.IPNet // Plages d'IP dont tous les clics sont considérés comme des robots
+}
+
+// NewBotClassifier crée un classificateur avec les plages d'IP (notation CIDR) à traiter
+// comme des robots, par exemple celles des sondes de supervision internes.
+func NewBotClassifier(ipRanges []string) (*BotClassifier, error) {
+	c := &BotClassifier{}
+	for _, cidr := range ipRanges {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bot IP range %q: %w", cidr, err)
+		}
+		c.ipRanges = append(c.ipRanges, network)
+	}
+	return c, nil
+}
+
+// Classify retourne true et la raison si l'événement provient d'un robot.
+// Les signaux utilisés sont, dans l'ordre : requête HEAD, User-Agent absent ou de robot connu,
+// marqueur de navigateur headless, IP appartenant à une plage configurée.
+func (c *BotClassifier) Classify(event models.ClickEvent, ua UserAgentInfo) (bool, string) {
+	if event.Method == http.MethodHead {
+		return true, "head request"
+	}
+	if strings.TrimSpace(event.UserAgent) == "" {
+		return true, "empty user agent"
+	}
+
+	lowerUA := strings.ToLower(event.UserAgent)
+	for _, marker := range headlessMarkers {
+		if strings.Contains(lowerUA, marker) {
+			return true, "headless browser"
+		}
+	}
+	if ua.DeviceType == DeviceBot {
+		return true, "known bot signature"
+	}
+
+	if c != nil && len(c.ipRanges) > 0 {
+		if ip := net.ParseIP(event.IP); ip != nil {
+			for _, network := range c.ipRanges {
+				if network.Contains(ip) {
+					return true, "ip range"
+				}
+			}
+		}
+	}
+	return false, ""
+}
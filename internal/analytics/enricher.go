@@ -0,0 +1,67 @@
+package analytics
+
+import "github.com/axellelanca/urlshortener/internal/models"
+
+// Enricher convertit un événement de clic brut en enregistrement models.Click enrichi :
+// domaine référent, analyse du User-Agent, détection des robots, hash de visiteur anonyme
+// et anonymisation de l'IP. Il est utilisé par les workers avant la persistance.
+type Enricher struct {
+	bots     *BotClassifier
+	visitors *VisitorHasher
+	ips      *IPAnonymizer
+}
+
+// NewEnricher crée un Enricher. 'bots' peut être nil : seuls les signaux ne dépendant pas
+// de la configuration (User-Agent, méthode HTTP) sont alors utilisés pour détecter les robots.
+// Si 'visitors' est nil, aucun hash de visiteur n'est calculé. Si 'ips' est nil, l'IP est
+// stockée telle quelle.
+func NewEnricher(bots *BotClassifier, visitors *VisitorHasher, ips *IPAnonymizer) *Enricher {
+	return &Enricher{bots: bots, visitors: visitors, ips: ips}
+}
+
+// Enrich construit le models.Click correspondant à l'événement.
+// L'IP complète n'est utilisée qu'en mémoire (plages d'IP de robots, hash de visiteur) ;
+// seule sa forme anonymisée est copiée dans le clic. En mode "none", elle est ignorée dès le départ.
+// Un événement déjà anonymisé (voir Anonymize) est repris tel quel.
+func (e *Enricher) Enrich(event models.ClickEvent) models.Click {
+	ua := ParseUserAgent(event.UserAgent)
+	if !event.Anonymized {
+		event = e.anonymize(event, ua)
+	}
+
+	return models.Click{
+		LinkID:         event.LinkID,
+		Timestamp:      event.Timestamp,
+		UserAgent:      event.UserAgent,
+		IP:             event.IP,
+		Referrer:       event.Referrer,
+		ReferrerDomain: NormalizeReferrer(event.Referrer),
+		BrowserFamily:  ua.BrowserFamily,
+		BrowserVersion: ua.BrowserVersion,
+		OS:             ua.OS,
+		DeviceType:     ua.DeviceType,
+		IsBot:          event.IsBot,
+		VisitorHash:    event.VisitorHash,
+	}
+}
+
+// Anonymize applique la politique de confidentialité à un événement avant qu'il ne quitte la
+// mémoire (écriture dans le spool durable) : la détection des robots et le hash de visiteur sont
+// calculés tout de suite, puis l'IP complète est remplacée par sa forme anonymisée.
+func (e *Enricher) Anonymize(event models.ClickEvent) models.ClickEvent {
+	if event.Anonymized {
+		return event
+	}
+	return e.anonymize(event, ParseUserAgent(event.UserAgent))
+}
+
+func (e *Enricher) anonymize(event models.ClickEvent, ua UserAgentInfo) models.ClickEvent {
+	if !e.ips.CapturesIP() {
+		event.IP = ""
+	}
+	event.IsBot, _ = e.bots.Classify(event, ua)
+	event.VisitorHash = e.visitors.Hash(event.IP, event.UserAgent, event.Timestamp)
+	event.IP = e.ips.Anonymize(event.IP)
+	event.Anonymized = true
+	return event
+}
@@ -0,0 +1,70 @@
+package analytics
+
+import (
+	"fmt"
+	"net"
+)
+
+// Modes de capture de l'adresse IP des visiteurs (analytics.privacy.ip_mode).
+const (
+	IPModeFull     = "full"     // IP stockée telle quelle
+	IPModeTruncate = "truncate" // IP tronquée à son préfixe réseau (ex: 192.168.1.0)
+	IPModeNone     = "none"     // IP jamais utilisée ni stockée
+)
+
+// IPAnonymizer applique la politique de confidentialité sur les adresses IP avant leur persistance.
+type IPAnonymizer struct {
+	mode   string
+	v4Mask net.IPMask
+	v6Mask net.IPMask
+}
+
+// NewIPAnonymizer crée un IPAnonymizer pour le mode donné. En mode "truncate", seuls les
+// 'ipv4Prefix' premiers bits des IPv4 et 'ipv6Prefix' premiers bits des IPv6 sont conservés.
+func NewIPAnonymizer(mode string, ipv4Prefix, ipv6Prefix int) (*IPAnonymizer, error) {
+	switch mode {
+	case "", IPModeFull:
+		return &IPAnonymizer{mode: IPModeFull}, nil
+	case IPModeNone:
+		return &IPAnonymizer{mode: IPModeNone}, nil
+	case IPModeTruncate:
+		if ipv4Prefix < 0 || ipv4Prefix > 32 {
+			return nil, fmt.Errorf("invalid IPv4 prefix length %d", ipv4Prefix)
+		}
+		if ipv6Prefix < 0 || ipv6Prefix > 128 {
+			return nil, fmt.Errorf("invalid IPv6 prefix length %d", ipv6Prefix)
+		}
+		return &IPAnonymizer{
+			mode:   IPModeTruncate,
+			v4Mask: net.CIDRMask(ipv4Prefix, 32),
+			v6Mask: net.CIDRMask(ipv6Prefix, 128),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown IP mode %q (expected full, truncate or none)", mode)
+	}
+}
+
+// CapturesIP indique si l'IP peut être utilisée, même transitoirement (mode différent de "none").
+func (a *IPAnonymizer) CapturesIP() bool {
+	return a == nil || a.mode != IPModeNone
+}
+
+// Anonymize retourne la forme de l'IP autorisée à être stockée : inchangée, tronquée ou vide.
+// Une valeur qui n'est pas une IP valide n'est jamais stockée en mode "truncate".
+func (a *IPAnonymizer) Anonymize(ip string) string {
+	if a == nil || a.mode == IPModeFull {
+		return ip
+	}
+	if a.mode == IPModeNone {
+		return ""
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(a.v4Mask).String()
+	}
+	return parsed.Mask(a.v6Mask).String()
+}
@@ -0,0 +1,30 @@
+package analytics
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeReferrer réduit la valeur brute de l'en-tête Referer au domaine référent :
+// hôte en minuscules, sans port ni préfixe "www.".
+// Retourne une chaîne vide pour un accès direct (en-tête absent) ou une valeur inexploitable.
+func NormalizeReferrer(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		// Certains clients envoient le domaine sans schéma (ex: "example.com/page").
+		u, err = url.Parse("http://" + raw)
+		if err != nil {
+			return ""
+		}
+	}
+
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimSuffix(host, ".")
+	host = strings.TrimPrefix(host, "www.")
+	return host
+}
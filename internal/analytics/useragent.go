@@ -0,0 +1,113 @@
+package analytics
+
+import (
+	_ "embed" // Pour embarquer le jeu de règles dans le binaire
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Types d'appareil détectés par ParseUserAgent.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// uaRulesJSON est le jeu de règles de détection, embarqué dans le binaire pour fonctionner hors ligne.
+//
+//go:embed ua_rules.json
+var uaRulesJSON []byte
+
+// UserAgentInfo est le résultat de l'analyse d'un User-Agent.
+// Les champs sont vides lorsque la valeur n'a pas pu être déterminée.
+type UserAgentInfo struct {
+	BrowserFamily  string
+	BrowserVersion string // Version majeure uniquement, pour limiter la cardinalité
+	OS             string
+	DeviceType     string // DeviceDesktop, DeviceMobile, DeviceTablet ou DeviceBot
+}
+
+// uaRule est une règle du fichier ua_rules.json. Elle s'applique si 'pattern' correspond
+// et que 'unless' (optionnel) ne correspond pas. Pour les navigateurs, le premier groupe
+// capturant de 'pattern' donne la version.
+type uaRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Unless  string `json:"unless"`
+
+	re     *regexp.Regexp
+	unless *regexp.Regexp
+}
+
+func (r *uaRule) match(ua string) []string {
+	m := r.re.FindStringSubmatch(ua)
+	if m == nil || (r.unless != nil && r.unless.MatchString(ua)) {
+		return nil
+	}
+	return m
+}
+
+// uaRuleSet regroupe les règles ordonnées : la première règle qui correspond l'emporte.
+type uaRuleSet struct {
+	Browsers []*uaRule `json:"browsers"`
+	OS       []*uaRule `json:"os"`
+	Devices  []*uaRule `json:"devices"`
+}
+
+var defaultUARules = mustLoadUARules(uaRulesJSON)
+
+// mustLoadUARules décode et compile le jeu de règles. Le fichier étant embarqué,
+// une erreur est une erreur de programmation : on panique au démarrage.
+func mustLoadUARules(data []byte) *uaRuleSet {
+	var rules uaRuleSet
+	if err := json.Unmarshal(data, &rules); err != nil {
+		panic(fmt.Sprintf("analytics: invalid ua_rules.json: %v", err))
+	}
+	for _, group := range [][]*uaRule{rules.Browsers, rules.OS, rules.Devices} {
+		for _, r := range group {
+			r.re = regexp.MustCompile(r.Pattern)
+			if r.Unless != "" {
+				r.unless = regexp.MustCompile(r.Unless)
+			}
+		}
+	}
+	return &rules
+}
+
+// ParseUserAgent extrait la famille et la version majeure du navigateur, le système
+// d'exploitation et le type d'appareil d'un User-Agent à l'aide du jeu de règles embarqué.
+// Un User-Agent non vide qui ne correspond à aucune règle d'appareil est considéré comme "desktop".
+func ParseUserAgent(ua string) UserAgentInfo {
+	var info UserAgentInfo
+	if ua == "" {
+		return info
+	}
+
+	for _, r := range defaultUARules.Browsers {
+		if m := r.match(ua); m != nil {
+			info.BrowserFamily = r.Name
+			if len(m) > 1 {
+				info.BrowserVersion = m[1]
+			}
+			break
+		}
+	}
+
+	for _, r := range defaultUARules.OS {
+		if r.match(ua) != nil {
+			info.OS = r.Name
+			break
+		}
+	}
+
+	info.DeviceType = DeviceDesktop
+	for _, r := range defaultUARules.Devices {
+		if r.match(ua) != nil {
+			info.DeviceType = r.Name
+			break
+		}
+	}
+	return info
+}
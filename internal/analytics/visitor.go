@@ -0,0 +1,98 @@
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// saltGracePeriod est la durée pendant laquelle le sel de la veille est conservé après minuit
+// (UTC), pour que les clics encore en file au changement de jour soient hachés avec le sel
+// du jour où ils ont eu lieu.
+const saltGracePeriod = 10 * time.Minute
+
+// VisitorHasher calcule un identifiant de visiteur anonyme à partir de l'IP et du User-Agent,
+// sans jamais stocker ces valeurs en clair dans l'identifiant.
+//
+// Le sel est tiré au hasard pour chaque jour (UTC) et n'existe qu'en mémoire : il est effacé
+// une fois la journée terminée, si bien qu'un même visiteur a le même hash toute la journée
+// mais que personne, même avec la base et la configuration, ne peut recalculer les hash des
+// jours passés ni relier des visites d'un jour à l'autre. Conséquences : un visiteur revenant
+// chaque jour est compté une fois par jour, et un redémarrage en cours de journée recompte
+// les visiteurs déjà vus.
+type VisitorHasher struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	day      string // Jour (UTC) du sel courant
+	salt     []byte
+	prevDay  string // Veille, conservée pendant saltGracePeriod
+	prevSalt []byte
+}
+
+// NewVisitorHasher crée un VisitorHasher. Les sels sont générés à la demande.
+func NewVisitorHasher() *VisitorHasher {
+	return &VisitorHasher{now: time.Now}
+}
+
+// Hash retourne l'identifiant anonyme du visiteur pour le jour (UTC) de 'at'.
+// Retourne une chaîne vide si ni l'IP ni le User-Agent ne sont connus.
+func (h *VisitorHasher) Hash(ip, userAgent string, at time.Time) string {
+	if h == nil || (ip == "" && userAgent == "") {
+		return ""
+	}
+
+	// Le MAC est calculé sous le verrou : un changement de jour concurrent efface l'ancien sel
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	visitorMAC := hmac.New(sha256.New, h.saltFor(at.UTC().Format(time.DateOnly)))
+	visitorMAC.Write([]byte(ip))
+	visitorMAC.Write([]byte{0})
+	visitorMAC.Write([]byte(userAgent))
+	return hex.EncodeToString(visitorMAC.Sum(nil))[:32]
+}
+
+// saltFor retourne le sel du jour demandé. Les sels des jours terminés sont effacés ; un clic
+// d'un jour dont le sel n'existe plus (rejeu tardif, horloge décalée) reçoit un sel jetable et
+// compte donc comme un nouveau visiteur. Doit être appelée avec h.mu verrouillé ; le sel
+// retourné n'est valide que tant que le verrou est détenu.
+func (h *VisitorHasher) saltFor(day string) []byte {
+	now := h.now().UTC()
+	today := now.Format(time.DateOnly)
+	if h.day != today {
+		forget(h.prevSalt)
+		h.prevDay, h.prevSalt = "", nil
+		if h.day == now.AddDate(0, 0, -1).Format(time.DateOnly) {
+			h.prevDay, h.prevSalt = h.day, h.salt
+		} else {
+			forget(h.salt)
+		}
+		h.day, h.salt = today, randomSalt()
+	}
+	if h.prevSalt != nil && now.Sub(now.Truncate(24*time.Hour)) >= saltGracePeriod {
+		forget(h.prevSalt)
+		h.prevDay, h.prevSalt = "", nil
+	}
+
+	switch {
+	case day == h.day:
+		return h.salt
+	case day == h.prevDay && h.prevSalt != nil:
+		return h.prevSalt
+	default:
+		return randomSalt()
+	}
+}
+
+func randomSalt() []byte {
+	salt := make([]byte, 32)
+	rand.Read(salt) // Ne retourne jamais d'erreur (voir crypto/rand)
+	return salt
+}
+
+// forget efface un sel avant de l'abandonner.
+func forget(salt []byte) {
+	clear(salt)
+}
@@ -4,10 +4,15 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm" // Pour gérer gorm.ErrRecordNotFound
 )
@@ -20,32 +25,81 @@ type ClickEvent = models.ClickEvent
 // aux workers asynchrones. Il est bufferisé pour ne pas bloquer les requêtes de redirection.
 var ClickEventsChannel chan models.ClickEvent
 
+// ClickSpool est la file durable des événements de clic (analytics.spool).
+// Lorsqu'elle est renseignée, RedirectHandler y écrit les événements au lieu du channel :
+// ils survivent alors aux pics de charge et aux redémarrages. nil = envoi direct dans le channel.
+var ClickSpool *spool.Spool
+
+// ClickEnricher anonymise les événements de clic avant leur écriture dans ClickSpool, pour
+// que l'IP complète des visiteurs ne soit jamais stockée sur disque (nil = événements bruts).
+var ClickEnricher *analytics.Enricher
+
+// BotClassifier détecte, avant la redirection, les visites de robots (requêtes HEAD, aperçus de
+// liens, crawlers) qui ne doivent pas consommer le budget de clics d'un lien.
+// nil = seuls les signaux indépendants de la configuration (méthode, User-Agent) sont utilisés.
+var BotClassifier *analytics.BotClassifier
+
+// ExpiredLinkFallbackURL est l'URL vers laquelle rediriger lorsqu'un lien a expiré, que son
+// budget de clics est épuisé ou qu'il a été désactivé. Si elle est vide, RedirectHandler répond 410 Gone.
+// Elle est renseignée par le serveur à partir de la configuration.
+var ExpiredLinkFallbackURL string
+
+// LinkStateChecker fournit l'état des destinations observé par le moniteur d'URLs.
+type LinkStateChecker interface {
+	// IsDown indique si la destination du lien est actuellement jugée inaccessible.
+	IsDown(linkID uint) bool
+}
+
+// LinkStates est la source de l'état des destinations consultée avant chaque redirection
+// (nil = les destinations sont toujours considérées comme accessibles).
+var LinkStates LinkStateChecker
+
+// DownLinkFallbackURL est l'URL de repli par défaut lorsqu'une destination est inaccessible
+// et que le lien n'a pas de FallbackURL (links.down_fallback_url).
+var DownLinkFallbackURL string
+
+// DownLinkStatusPage indique si, sans URL de repli, une page d'état est affichée au lieu de
+// rediriger vers une destination inaccessible (links.down_status_page).
+var DownLinkStatusPage bool
+
 // SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService) {
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickService *services.ClickService, healthService *services.LinkHealthService, maintenanceService *services.MaintenanceService, checker LinkChecker) {
 	// Le channel n'est plus initialisé ici (il est injecté par server via RegisterRoutes)
 
 	// Route de Health Check , /health
 	router.GET("/health", HealthCheckHandler)
+	router.GET("/metrics", MetricsHandler)
 
 	router.POST("/links", CreateShortLinkHandler(linkService))
-	router.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+	router.GET("/links", ListLinksHandler(linkService))
+	router.PATCH("/links/:shortCode", UpdateLinkHandler(linkService))
+	router.DELETE("/links/:shortCode", DeleteLinkHandler(linkService))
+	router.POST("/links/:shortCode/restore", RestoreLinkHandler(linkService))
+	router.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService, clickService))
+	router.GET("/links/:shortCode/stats/timeseries", GetLinkTimeSeriesHandler(linkService, clickService))
+	router.GET("/links/:shortCode/stats/referrers", GetLinkBreakdownHandler(linkService, clickService, repository.DimensionReferrer))
+	router.GET("/links/:shortCode/stats/browsers", GetLinkBreakdownHandler(linkService, clickService, repository.DimensionBrowser))
+	router.GET("/links/:shortCode/stats/os", GetLinkBreakdownHandler(linkService, clickService, repository.DimensionOS))
+	router.GET("/links/:shortCode/stats/devices", GetLinkBreakdownHandler(linkService, clickService, repository.DimensionDevice))
+	router.GET("/links/:shortCode/health", GetLinkHealthHandler(linkService, healthService))
+	router.POST("/links/:shortCode/check", CheckLinkHandler(linkService, checker))
+	router.GET("/maintenance-windows", ListMaintenanceWindowsHandler(maintenanceService))
+	router.POST("/maintenance-windows", CreateMaintenanceWindowHandler(maintenanceService))
+	router.DELETE("/maintenance-windows/:id", DeleteMaintenanceWindowHandler(maintenanceService))
 
 	// Route de Redirection (au niveau racine pour les short codes)
 	router.GET("/:shortCode", RedirectHandler(linkService))
+	router.HEAD("/:shortCode", RedirectHandler(linkService))
 }
 
-// RegisterRoutes — ajout minimal pour satisfaire l'appel dans server.go
-// Ne change rien au comportement existant : on réutilise les mêmes handlers.
-// On stocke simplement le channel passé par server.go.
-func RegisterRoutes(router *gin.Engine, linkService *services.LinkService, _ *services.ClickService, clickEvents chan ClickEvent) {
+// RegisterRoutes — point d'entrée utilisé par server.go.
+// On stocke le channel passé par le serveur puis on déclare les routes via SetupRoutes,
+// pour que les deux fonctions ne puissent pas diverger.
+func RegisterRoutes(router *gin.Engine, linkService *services.LinkService, clickService *services.ClickService, healthService *services.LinkHealthService, maintenanceService *services.MaintenanceService, checker LinkChecker, clickEvents chan ClickEvent) {
 	// On utilise le channel fourni par le serveur
 	ClickEventsChannel = clickEvents
 
-	// Routes identiques à SetupRoutes (sans réinitialiser le channel ici)
-	router.GET("/health", HealthCheckHandler)
-	router.POST("/links", CreateShortLinkHandler(linkService))
-	router.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
-	router.GET("/:shortCode", RedirectHandler(linkService))
+	SetupRoutes(router, linkService, clickService, healthService, maintenanceService, checker)
 }
 
 // HealthCheckHandler gère la route /health pour vérifier l'état du service.
@@ -54,9 +108,22 @@ func HealthCheckHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// MetricsHandler expose les métriques de l'application au format texte Prometheus.
+func MetricsHandler(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := metrics.Default.WriteText(c.Writer); err != nil {
+		log.Printf("Error writing metrics: %v", err)
+	}
+}
+
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
-	LongURL string `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
+	LongURL     string     `json:"long_url" binding:"required,url"`      // 'binding:required' pour validation, 'url' pour format URL
+	Alias       string     `json:"alias"`                                // Code court personnalisé (optionnel)
+	ExpiresAt   *time.Time `json:"expires_at"`                           // Date d'expiration RFC3339 (optionnelle)
+	MaxClicks   int        `json:"max_clicks" binding:"gte=0"`           // Budget de clics (optionnel, 0 = illimité)
+	FallbackURL string     `json:"fallback_url" binding:"omitempty,url"` // URL de repli tant que la destination est inaccessible (optionnelle)
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
@@ -68,68 +135,433 @@ func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
 			return
 		}
 
-		link, err := linkService.CreateLink(req.LongURL)
+		link, err := linkService.CreateLink(req.LongURL, services.CreateLinkOptions{
+			Alias:       req.Alias,
+			ExpiresAt:   req.ExpiresAt,
+			MaxClicks:   req.MaxClicks,
+			FallbackURL: req.FallbackURL,
+		})
 		if err != nil {
+			if errors.Is(err, services.ErrAliasTaken) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Alias already in use"})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidAlias) || errors.Is(err, services.ErrInvalidLifetime) ||
+				errors.Is(err, services.ErrInvalidFallbackURL) || errors.Is(err, services.ErrForbiddenDestination) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			log.Printf("Error creating short link for %s: %v", req.LongURL, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create short link"})
 			return
 		}
 
-		// Construit l'URL courte complète à partir de l'hôte de la requête
-		host := c.Request.Host
-		if host == "" {
-			host = "localhost:8080"
+		metrics.LinksCreated.Inc()
+		c.JSON(http.StatusCreated, linkResponse(c, link))
+	}
+}
+
+// linkResponse construit la représentation JSON d'un lien renvoyée par l'API.
+func linkResponse(c *gin.Context, link *models.Link) gin.H {
+	// Construit l'URL courte complète à partir de l'hôte de la requête
+	host := c.Request.Host
+	if host == "" {
+		host = "localhost:8080"
+	}
+
+	resp := gin.H{
+		"short_code":     link.ShortCode,
+		"long_url":       link.LongURL,
+		"full_short_url": "http://" + host + "/" + link.ShortCode,
+		"created_at":     time.Unix(link.CreatedAt, 0).UTC(),
+		"status":         link.Status(time.Now()),
+		"disabled":       link.Disabled,
+	}
+	if link.Disabled {
+		resp["disabled_at"] = link.DisabledAt
+		resp["auto_disabled"] = link.AutoDisabled
+		if link.DisabledReason != "" {
+			resp["disabled_reason"] = link.DisabledReason
 		}
+	}
+	if link.ExpiresAt != nil {
+		resp["expires_at"] = link.ExpiresAt
+	}
+	if link.MaxClicks > 0 {
+		resp["max_clicks"] = link.MaxClicks
+		resp["used_clicks"] = link.UsedClicks
+	}
+	if link.FallbackURL != "" {
+		resp["fallback_url"] = link.FallbackURL
+	}
+	if link.PrivateDestination {
+		resp["private_destination"] = true
+	}
+	if !link.Monitor.IsZero() {
+		resp["monitor"] = monitorSettingsResponse(link.Monitor)
+	}
+	return resp
+}
 
-		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.ShortCode,
-			"long_url":       link.LongURL,
-			"full_short_url": "http://" + host + "/" + link.ShortCode,
-		})
+// monitorSettingsResponse ne retourne que les réglages de surveillance surchargés par le lien.
+func monitorSettingsResponse(settings models.LinkMonitorSettings) gin.H {
+	resp := gin.H{}
+	if settings.Enabled != nil {
+		resp["enabled"] = *settings.Enabled
+	}
+	if settings.IntervalMinutes != nil {
+		resp["interval_minutes"] = *settings.IntervalMinutes
+	}
+	if settings.AcceptedStatusCodes != "" {
+		resp["accepted_status_codes"] = settings.AcceptedStatusCodes
+	}
+	if settings.FailureThreshold != nil {
+		resp["failure_threshold"] = *settings.FailureThreshold
+	}
+	if settings.UserAgent != "" {
+		resp["user_agent"] = settings.UserAgent
+	}
+	if settings.GetFallback != nil {
+		resp["get_fallback"] = *settings.GetFallback
+	}
+	if settings.ContentFingerprint != nil {
+		resp["content_fingerprint"] = *settings.ContentFingerprint
+	}
+	return resp
+}
+
+// ListLinksHandler gère le listing paginé des liens.
+// Paramètres de requête : limit, cursor, sort (created_at|clicks), order (asc|desc),
+// q (sous-chaîne de l'URL longue), created_after, created_before (RFC3339 ou YYYY-MM-DD)
+// et status (active|disabled|expired|deleted).
+func ListLinksHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		params := repository.LinkListParams{
+			Cursor: c.Query("cursor"),
+			SortBy: c.Query("sort"),
+			Search: c.Query("q"),
+			Status: c.Query("status"),
+		}
+
+		if limit := c.Query("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer"})
+				return
+			}
+			params.Limit = n
+		}
+
+		switch c.DefaultQuery("order", "desc") {
+		case "asc":
+			params.Ascending = true
+		case "desc":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+			return
+		}
+
+		var ok bool
+		if params.CreatedAfter, ok = optionalTimeQuery(c, "created_after", time.UTC); !ok {
+			return
+		}
+		if params.CreatedBefore, ok = optionalTimeQuery(c, "created_before", time.UTC); !ok {
+			return
+		}
+
+		page, err := linkService.ListLinks(params)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidListQuery) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error listing links: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		links := make([]gin.H, 0, len(page.Items))
+		for i := range page.Items {
+			item := linkResponse(c, &page.Items[i].Link)
+			item["total_clicks"] = page.Items[i].TotalClicks
+			links = append(links, item)
+		}
+
+		resp := gin.H{"links": links}
+		if page.NextCursor != "" {
+			resp["next_cursor"] = page.NextCursor
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// includeBotsQuery indique si la requête demande d'inclure les clics de robots (include_bots=true).
+func includeBotsQuery(c *gin.Context) bool {
+	include, err := strconv.ParseBool(c.DefaultQuery("include_bots", "false"))
+	return err == nil && include
+}
+
+// optionalTimeQuery lit un paramètre de requête de type date (voir services.ParseTimeParam).
+// Retourne nil si le paramètre est absent. Si la valeur est invalide, une réponse 400 est
+// envoyée et ok vaut false.
+func optionalTimeQuery(c *gin.Context, name string, loc *time.Location) (t *time.Time, ok bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	parsed, err := services.ParseTimeParam(value, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + ": " + err.Error()})
+		return nil, false
+	}
+	return &parsed, true
+}
+
+// UpdateLinkRequest représente le corps de la requête JSON PATCH /links/:shortCode.
+// Les champs absents ne sont pas modifiés.
+type UpdateLinkRequest struct {
+	LongURL     *string                 `json:"long_url" binding:"omitempty,url"` // Nouvelle URL de destination
+	Disabled    *bool                   `json:"disabled"`                         // true pour désactiver la redirection, false pour la réactiver
+	FallbackURL *string                 `json:"fallback_url"`                     // URL de repli, "" pour revenir au repli global
+	Monitor     *MonitorSettingsRequest `json:"monitor"`                          // Réglages de surveillance propres au lien
+}
+
+// MonitorSettingsRequest représente les réglages de surveillance d'un lien dans UpdateLinkRequest.
+// Une valeur vide (ou un seuil à 0) rétablit le réglage global ; "reset" les rétablit tous.
+type MonitorSettingsRequest struct {
+	Reset               bool    `json:"reset"`
+	Enabled             *bool   `json:"enabled"`               // false = lien exclu de la surveillance
+	IntervalMinutes     *int    `json:"interval_minutes"`      // Intervalle propre au lien (0 = global)
+	AcceptedStatusCodes *string `json:"accepted_status_codes"` // ex: "200-299,401" ou "2xx,3xx"
+	FailureThreshold    *int    `json:"failure_threshold"`
+	UserAgent           *string `json:"user_agent"`
+	GetFallback         *bool   `json:"get_fallback"`
+	ContentFingerprint  *bool   `json:"content_fingerprint"`
+}
+
+// UpdateLinkHandler gère la modification de la destination d'un lien et son activation/désactivation.
+func UpdateLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+
+		opts := services.UpdateLinkOptions{
+			LongURL:     req.LongURL,
+			Disabled:    req.Disabled,
+			FallbackURL: req.FallbackURL,
+		}
+		if req.Monitor != nil {
+			opts.Monitor = &services.MonitorSettingsUpdate{
+				Reset:               req.Monitor.Reset,
+				Enabled:             req.Monitor.Enabled,
+				IntervalMinutes:     req.Monitor.IntervalMinutes,
+				AcceptedStatusCodes: req.Monitor.AcceptedStatusCodes,
+				FailureThreshold:    req.Monitor.FailureThreshold,
+				UserAgent:           req.Monitor.UserAgent,
+				GetFallback:         req.Monitor.GetFallback,
+				ContentFingerprint:  req.Monitor.ContentFingerprint,
+			}
+		}
+
+		link, err := linkService.UpdateLink(shortCode, opts)
+		if err != nil {
+			if errors.Is(err, services.ErrNothingToUpdate) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "No field to update"})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidMonitorSettings) || errors.Is(err, services.ErrInvalidFallbackURL) ||
+				errors.Is(err, services.ErrForbiddenDestination) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				return
+			}
+			log.Printf("Error updating link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(c, link))
+	}
+}
+
+// DeleteLinkHandler gère la suppression logique d'un lien.
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		if err := linkService.DeleteLink(shortCode); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				return
+			}
+			log.Printf("Error deleting link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RestoreLinkHandler gère la restauration d'un lien supprimé logiquement.
+func RestoreLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.RestoreLink(shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Deleted link not found"})
+				return
+			}
+			log.Printf("Error restoring link %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, linkResponse(c, link))
 	}
 }
 
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue et l'enregistrement asynchrone des clics.
 func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		start := time.Now()
+		defer func() {
+			status := strconv.Itoa(c.Writer.Status())
+			metrics.Redirects.Inc(status)
+			metrics.RedirectDuration.Observe(time.Since(start).Seconds(), status)
+		}()
+
 		// Récupère le shortCode de l'URL avec c.Param
 		shortCode := c.Param("shortCode")
 
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		link, err := linkService.ResolveRedirect(shortCode)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
 				return
 			}
+			if errors.Is(err, services.ErrLinkExpired) || errors.Is(err, services.ErrClickBudgetExhausted) ||
+				errors.Is(err, services.ErrLinkDisabled) {
+				respondLinkGone(c, err)
+				return
+			}
 			log.Printf("Error retrieving link for %s: %v", shortCode, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 			return
 		}
 
+		// Une visite détournée (destination jugée inaccessible) n'est ni comptée ni décomptée du
+		// budget de clics : le visiteur n'a pas atteint la destination du lien.
+		if LinkStates != nil && LinkStates.IsDown(link.ID) && respondLinkDown(c, link) {
+			return
+		}
+
 		clickEvent := models.ClickEvent{
 			LinkID:    link.ID,
 			Timestamp: time.Now(),
 			UserAgent: c.Request.UserAgent(),
 			IP:        c.ClientIP(),
+			Referrer:  c.Request.Referer(),
+			Method:    c.Request.Method,
 		}
 
-		select {
-		case ClickEventsChannel <- clickEvent:
-			// enqueued
-		default:
-			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+		// Seules les visites humaines consomment le budget de clics : un aperçu de lien
+		// (Slack, Twitter...) ou une sonde HEAD ne doit pas épuiser un lien à usage unique.
+		if isBot, _ := BotClassifier.Classify(clickEvent, analytics.ParseUserAgent(clickEvent.UserAgent)); !isBot {
+			if err := linkService.ConsumeClick(link); err != nil {
+				if errors.Is(err, services.ErrClickBudgetExhausted) {
+					respondLinkGone(c, err)
+					return
+				}
+				log.Printf("Error consuming click budget for %s: %v", shortCode, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+		}
+
+		if ClickSpool != nil {
+			if ClickEnricher != nil {
+				clickEvent = ClickEnricher.Anonymize(clickEvent)
+			}
+			if err := ClickSpool.Append(clickEvent); err != nil {
+				metrics.ClickEventsDropped.Inc()
+				log.Printf("Warning: failed to spool click event for %s, dropping it: %v", shortCode, err)
+			}
+		} else {
+			select {
+			case ClickEventsChannel <- clickEvent:
+				// enqueued
+			default:
+				metrics.ClickEventsDropped.Inc()
+				log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+			}
 		}
 
 		c.Redirect(http.StatusFound, link.LongURL)
 	}
 }
 
+// respondLinkDown répond à une redirection dont la destination est jugée inaccessible par le
+// moniteur : redirection vers l'URL de repli du lien, ou à défaut vers DownLinkFallbackURL, ou
+// page d'état si DownLinkStatusPage est activé. Retourne false si la redirection doit avoir lieu
+// normalement.
+func respondLinkDown(c *gin.Context, link *models.Link) bool {
+	fallbackURL := link.FallbackURL
+	if fallbackURL == "" {
+		fallbackURL = DownLinkFallbackURL
+	}
+	switch {
+	case fallbackURL != "":
+		metrics.RedirectFallbacks.Inc("fallback_url")
+		c.Redirect(http.StatusFound, fallbackURL)
+	case DownLinkStatusPage:
+		metrics.RedirectFallbacks.Inc("status_page")
+		renderDownStatusPage(c, link)
+	default:
+		return false
+	}
+	return true
+}
+
+// respondLinkGone répond à une redirection vers un lien qui n'est plus disponible
+// (expiré, budget de clics épuisé ou désactivé) :
+// redirection vers ExpiredLinkFallbackURL si elle est configurée, 410 Gone sinon.
+func respondLinkGone(c *gin.Context, reason error) {
+	if ExpiredLinkFallbackURL != "" {
+		c.Redirect(http.StatusFound, ExpiredLinkFallbackURL)
+		return
+	}
+	c.JSON(http.StatusGone, gin.H{"error": "Link is no longer available", "reason": reason.Error()})
+}
+
 // GetLinkStatsHandler gère la récupération des statistiques pour un lien spécifique.
-func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
+// Le paramètre de requête 'top' (défaut: 5) fixe le nombre de domaines référents retournés et
+// 'include_bots' (défaut: false) indique si les clics de robots sont comptés dans cette répartition
+// et dans les visiteurs.
+// Les compteurs total_clicks (robots inclus) et human_clicks sont toujours renvoyés.
+// unique_visitors ne porte que sur les clics bruts ; aggregated_visitor_days additionne les visiteurs
+// uniques de chaque jour déjà agrégé par la politique de rétention.
+func GetLinkStatsHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		top, err := strconv.Atoi(c.DefaultQuery("top", "5"))
+		if err != nil || top < 1 || top > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "top must be an integer between 1 and 100"})
+			return
+		}
+
+		link, counts, err := linkService.GetLinkStats(shortCode)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
@@ -140,10 +572,36 @@ func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
 			return
 		}
 
+		topReferrers, err := clickService.GetClickBreakdown(link.ID, repository.DimensionReferrer, top, includeBotsQuery(c))
+		if err != nil {
+			log.Printf("Error retrieving referrers for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		uniqueVisitors, err := clickService.GetUniqueVisitors(link.ID, includeBotsQuery(c))
+		if err != nil {
+			log.Printf("Error counting unique visitors for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		aggregatedVisitorDays, err := clickService.GetAggregatedVisitorDays(link.ID, includeBotsQuery(c))
+		if err != nil {
+			log.Printf("Error counting aggregated visitors for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
-			"short_code":   link.ShortCode,
-			"long_url":     link.LongURL,
-			"total_clicks": totalClicks,
+			"short_code":              link.ShortCode,
+			"long_url":                link.LongURL,
+			"total_clicks":            counts.Total,
+			"human_clicks":            counts.Human,
+			"bot_clicks":              counts.Bots(),
+			"unique_visitors":         uniqueVisitors,
+			"aggregated_visitor_days": aggregatedVisitorDays,
+			"top_referrers":           topReferrers,
 		})
 	}
 }
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetLinkHealthHandler gère la récupération de l'état de santé d'un lien observé par le moniteur.
+// Paramètres de requête : window_hours (période du calcul de disponibilité, défaut: 24)
+// et limit (nombre de vérifications de l'historique, défaut: 20).
+func GetLinkHealthHandler(linkService *services.LinkService, healthService *services.LinkHealthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		windowHours, err := strconv.Atoi(c.DefaultQuery("window_hours", "24"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window_hours must be an integer"})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer"})
+			return
+		}
+
+		link, err := linkService.GetLinkByShortCode(shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		health, err := healthService.GetLinkHealth(link.ID, time.Duration(windowHours)*time.Hour, limit)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidHealthQuery) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error retrieving health for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		history := make([]gin.H, 0, len(health.History))
+		for _, check := range health.History {
+			history = append(history, linkCheckResponse(check))
+		}
+
+		var lastCheckedAt *time.Time
+		if health.LastCheck != nil {
+			lastCheckedAt = &health.LastCheck.CheckedAt
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":      link.ShortCode,
+			"long_url":        link.LongURL,
+			"status":          health.Status,
+			"last_checked_at": lastCheckedAt,
+			"window_hours":    windowHours,
+			"checks":          health.Checks,
+			"uptime_percent":  health.Uptime,
+			"history":         history,
+		})
+	}
+}
+
+// LinkChecker vérifie immédiatement la destination d'un lien et enregistre le résultat
+// (implémenté par monitor.UrlMonitor).
+type LinkChecker interface {
+	CheckNow(ctx context.Context, link models.Link) (models.LinkCheck, error)
+}
+
+// CheckLinkHandler gère la vérification immédiate de la destination d'un lien. Le résultat est
+// enregistré dans l'historique comme une vérification périodique et retourné en détail.
+func CheckLinkHandler(linkService *services.LinkService, checker LinkChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		link, err := linkService.GetLinkByShortCode(shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		if !link.Monitor.IsEnabled() {
+			c.JSON(http.StatusConflict, gin.H{"error": "Monitoring is disabled for this link"})
+			return
+		}
+
+		check, err := checker.CheckNow(c.Request.Context(), *link)
+		if err != nil {
+			log.Printf("Check of %s interrupted: %v", shortCode, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Check interrupted"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.ShortCode,
+			"long_url":   link.LongURL,
+			"status":     healthState(check),
+			"check":      linkCheckResponse(check),
+		})
+	}
+}
+
+// linkCheckResponse construit la représentation JSON d'une vérification.
+func linkCheckResponse(check models.LinkCheck) gin.H {
+	return gin.H{
+		"checked_at":           check.CheckedAt,
+		"accessible":           check.Accessible,
+		"status_code":          check.StatusCode,
+		"latency_ms":           check.LatencyMs,
+		"error_class":          check.ErrorClass,
+		"final_url":            check.FinalURL,
+		"method":               check.Method,
+		"state":                healthState(check),
+		"consecutive_failures": check.ConsecutiveFailures,
+		"failing_since":        check.FailingSince,
+		"redirect_chain":       redirectChainResponse(check.RedirectChain),
+		"https_downgrade":      check.HTTPSDowngrade,
+		"tls_expires_at":       check.TLSNotAfter,
+		"content_hash":         check.ContentHash,
+		"page_title":           check.PageTitle,
+		"content_changed":      check.ContentChanged,
+		"maintenance":          check.Maintenance,
+	}
+}
+
+// redirectChainResponse retourne la chaîne de redirections, vide plutôt que null.
+func redirectChainResponse(hops []models.RedirectHop) []models.RedirectHop {
+	if hops == nil {
+		return []models.RedirectHop{}
+	}
+	return hops
+}
+
+// healthState retourne l'état retenu par le moniteur après une vérification.
+func healthState(check models.LinkCheck) string {
+	if check.ErrorClass == models.CheckErrorBlocked {
+		return services.HealthStatusUnknown // Destination refusée par la politique d'URL, non vérifiée
+	}
+	if check.IsUp() {
+		return services.HealthStatusUp
+	}
+	return services.HealthStatusDown
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateMaintenanceWindowRequest représente le corps de la requête JSON de création d'une fenêtre de maintenance.
+type CreateMaintenanceWindowRequest struct {
+	ShortCode string     `json:"short_code"`                 // Lien concerné ; vide = tous les liens
+	StartsAt  *time.Time `json:"starts_at"`                  // Début RFC3339 (optionnel, défaut: maintenant)
+	EndsAt    time.Time  `json:"ends_at" binding:"required"` // Fin RFC3339
+	Reason    string     `json:"reason"`                     // Motif affiché dans la liste des fenêtres
+}
+
+// CreateMaintenanceWindowHandler gère la déclaration d'une fenêtre de maintenance, globale ou propre à un lien.
+func CreateMaintenanceWindowHandler(maintenanceService *services.MaintenanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CreateMaintenanceWindowRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		startsAt := time.Now()
+		if req.StartsAt != nil {
+			startsAt = *req.StartsAt
+		}
+
+		window, err := maintenanceService.CreateWindow(req.ShortCode, startsAt, req.EndsAt, req.Reason)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				return
+			}
+			if errors.Is(err, services.ErrInvalidMaintenanceWindow) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error creating maintenance window: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, maintenanceWindowResponse(*window, time.Now()))
+	}
+}
+
+// ListMaintenanceWindowsHandler gère la liste des fenêtres de maintenance en cours et à venir
+// (toutes avec ?all=true).
+func ListMaintenanceWindowsHandler(maintenanceService *services.MaintenanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		includeEnded, err := strconv.ParseBool(c.DefaultQuery("all", "false"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "all must be a boolean"})
+			return
+		}
+
+		windows, err := maintenanceService.ListWindows(includeEnded)
+		if err != nil {
+			log.Printf("Error listing maintenance windows: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		now := time.Now()
+		items := make([]gin.H, 0, len(windows))
+		for _, window := range windows {
+			items = append(items, maintenanceWindowResponse(window, now))
+		}
+		c.JSON(http.StatusOK, gin.H{"maintenance_windows": items})
+	}
+}
+
+// DeleteMaintenanceWindowHandler gère la suppression d'une fenêtre de maintenance.
+func DeleteMaintenanceWindowHandler(maintenanceService *services.MaintenanceService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id must be an integer"})
+			return
+		}
+
+		if err := maintenanceService.DeleteWindow(uint(id)); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Maintenance window not found"})
+				return
+			}
+			log.Printf("Error deleting maintenance window %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// maintenanceWindowResponse construit la représentation JSON d'une fenêtre de maintenance.
+// Le code court est vide pour une fenêtre globale.
+func maintenanceWindowResponse(window models.MaintenanceWindow, now time.Time) gin.H {
+	shortCode := ""
+	if window.Link != nil {
+		shortCode = window.Link.ShortCode
+	}
+	return gin.H{
+		"id":         window.ID,
+		"short_code": shortCode,
+		"global":     window.LinkID == nil,
+		"starts_at":  window.StartsAt,
+		"ends_at":    window.EndsAt,
+		"reason":     window.Reason,
+		"active":     window.IsActive(now),
+	}
+}
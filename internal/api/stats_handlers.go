@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultTimeSeriesRange est la période couverte par défaut lorsque 'from' n'est pas fourni.
+var defaultTimeSeriesRange = map[string]time.Duration{
+	services.IntervalHour: 24 * time.Hour,
+	services.IntervalDay:  30 * 24 * time.Hour,
+	services.IntervalWeek: 12 * 7 * 24 * time.Hour,
+}
+
+// GetLinkTimeSeriesHandler gère la récupération du nombre de clics d'un lien par période.
+// Paramètres de requête : from, to (RFC3339 ou YYYY-MM-DD), interval (hour|day|week, défaut: day)
+// tz (fuseau IANA, ex: Europe/Paris, défaut: UTC) et include_bots (défaut: false).
+func GetLinkTimeSeriesHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		loc, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time zone"})
+			return
+		}
+
+		interval := c.DefaultQuery("interval", services.IntervalDay)
+		defaultRange, ok := defaultTimeSeriesRange[interval]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be hour, day or week"})
+			return
+		}
+
+		from, ok := optionalTimeQuery(c, "from", loc)
+		if !ok {
+			return
+		}
+		to, ok := optionalTimeQuery(c, "to", loc)
+		if !ok {
+			return
+		}
+		if to == nil {
+			now := time.Now()
+			to = &now
+		}
+		if from == nil {
+			start := to.Add(-defaultRange)
+			from = &start
+		}
+
+		link, err := linkService.GetLinkByShortCode(shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		buckets, err := clickService.GetClickTimeSeries(link.ID, *from, *to, interval, loc, includeBotsQuery(c))
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeSeries) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			log.Printf("Error retrieving time series for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":   link.ShortCode,
+			"include_bots": includeBotsQuery(c),
+			"from":         from.In(loc),
+			"to":           to.In(loc),
+			"interval":     interval,
+			"timezone":     loc.String(),
+			"buckets":      buckets,
+		})
+	}
+}
+
+// GetLinkBreakdownHandler gère la répartition des clics d'un lien selon une dimension
+// (référent, navigateur, système d'exploitation ou type d'appareil).
+// Paramètres de requête : limit (défaut: 10), include_bots (défaut: false) et, pour les
+// navigateurs, versions=true pour distinguer les versions majeures.
+func GetLinkBreakdownHandler(linkService *services.LinkService, clickService *services.ClickService, dimension string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be an integer between 1 and 100"})
+			return
+		}
+
+		dim := dimension
+		if dim == repository.DimensionBrowser && c.Query("versions") == "true" {
+			dim = repository.DimensionBrowserVersion
+		}
+
+		link, err := linkService.GetLinkByShortCode(shortCode)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+				return
+			}
+			log.Printf("Error retrieving link for %s: %v", shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		entries, err := clickService.GetClickBreakdown(link.ID, dim, limit, includeBotsQuery(c))
+		if err != nil {
+			log.Printf("Error retrieving %s breakdown for %s: %v", dim, shortCode, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.ShortCode,
+			"dimension":  dim,
+			"breakdown":  entries,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// downStatusPage est la page affichée lorsque la destination d'un lien est inaccessible
+// et qu'aucune URL de repli n'est configurée.
+var downStatusPage = template.Must(template.New("down").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Destination momentanément indisponible</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 36rem; margin: 4rem auto; padding: 0 1rem; color: #222; }
+code { background: #f2f2f2; padding: .1rem .3rem; border-radius: 3px; }
+a { color: #0b5fff; }
+</style>
+</head>
+<body>
+<h1>Destination momentanément indisponible</h1>
+<p>Le lien <code>{{.ShortCode}}</code> pointe vers <strong>{{.Host}}</strong>, qui ne répond pas correctement d'après nos dernières vérifications.</p>
+<p>Réessayez dans quelques minutes, ou tentez votre chance directement :
+<a href="{{.LongURL}}" rel="nofollow noopener">{{.LongURL}}</a></p>
+</body>
+</html>
+`))
+
+// renderDownStatusPage répond 503 avec une page expliquant que la destination du lien est
+// inaccessible (HTML pour les navigateurs, JSON pour les clients d'API).
+func renderDownStatusPage(c *gin.Context, link *models.Link) {
+	c.Header("Cache-Control", "no-store")
+
+	if c.NegotiateFormat(binding.MIMEHTML, binding.MIMEJSON) == binding.MIMEJSON {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":      "Link destination is currently unreachable",
+			"short_code": link.ShortCode,
+			"long_url":   link.LongURL,
+		})
+		return
+	}
+
+	host := link.LongURL
+	if u, err := url.Parse(link.LongURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	c.Status(http.StatusServiceUnavailable)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	err := downStatusPage.Execute(c.Writer, struct {
+		ShortCode, Host, LongURL string
+	}{link.ShortCode, host, link.LongURL})
+	if err != nil {
+		log.Printf("Error rendering status page for %s: %v", link.ShortCode, err)
+	}
+}
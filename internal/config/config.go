@@ -12,19 +12,79 @@ import (
 // (ou des variables d'environnement) aux champs de la structure Go.
 type Config struct {
 	Server struct {
-		Port    int    `mapstructure:"port"`
-		BaseURL string `mapstructure:"base_url"`
+		Port                   int    `mapstructure:"port"`
+		BaseURL                string `mapstructure:"base_url"`
+		ShutdownTimeoutSeconds int    `mapstructure:"shutdown_timeout_seconds"`
 	} `mapstructure:"server"`
 	Database struct {
 		Name string `mapstructure:"name"`
 	} `mapstructure:"database"`
 	Analytics struct {
-		BufferSize int `mapstructure:"buffer_size"`
-		WorkerCount int `mapstructure:"worker_count"`
+		BufferSize           int      `mapstructure:"buffer_size"`
+		WorkerCount          int      `mapstructure:"worker_count"`
+		BatchSize            int      `mapstructure:"batch_size"`
+		BatchFlushIntervalMs int      `mapstructure:"batch_flush_interval_ms"`
+		BotIPRanges          []string `mapstructure:"bot_ip_ranges"`
+		Privacy              struct {
+			IPMode             string `mapstructure:"ip_mode"`
+			IPv4PrefixLength   int    `mapstructure:"ipv4_prefix_length"`
+			IPv6PrefixLength   int    `mapstructure:"ipv6_prefix_length"`
+			RetentionDays      int    `mapstructure:"retention_days"`
+			RetentionMode      string `mapstructure:"retention_mode"`
+			PurgeIntervalHours int    `mapstructure:"purge_interval_hours"`
+		} `mapstructure:"privacy"`
+		Spool struct {
+			Enabled      bool   `mapstructure:"enabled"`
+			Dir          string `mapstructure:"dir"`
+			MaxSegmentMB int    `mapstructure:"max_segment_mb"`
+			MaxTotalMB   int    `mapstructure:"max_total_mb"`
+			SyncWrites   bool   `mapstructure:"sync_writes"`
+		} `mapstructure:"spool"`
 	} `mapstructure:"analytics"`
 	Monitor struct {
-		IntervalMinutes int `mapstructure:"interval_minutes"`
+		IntervalMinutes          int              `mapstructure:"interval_minutes"`
+		HistoryDays              int              `mapstructure:"history_days"`
+		Notifiers                []NotifierConfig `mapstructure:"notifiers"`
+		Concurrency              int              `mapstructure:"concurrency"`
+		PerHostConcurrency       int              `mapstructure:"per_host_concurrency"`
+		PerHostRequestsPerSecond float64          `mapstructure:"per_host_requests_per_second"`
+		RequestTimeoutSeconds    int              `mapstructure:"request_timeout_seconds"`
+		AcceptedStatusCodes      string           `mapstructure:"accepted_status_codes"`
+		FailureThreshold         int              `mapstructure:"failure_threshold"`
+		UserAgent                string           `mapstructure:"user_agent"`
+		GetFallback              bool             `mapstructure:"get_fallback"`
+		GetFallbackMaxBytes      int64            `mapstructure:"get_fallback_max_bytes"`
+		TLSExpiryWarningDays     int              `mapstructure:"tls_expiry_warning_days"`
+		ContentFingerprint       bool             `mapstructure:"content_fingerprint"`
+		ContentMaxBytes          int64            `mapstructure:"content_max_bytes"`
+		ContentChangeBits        int              `mapstructure:"content_change_bits"`
+		AutoDisableAfterDays     int              `mapstructure:"auto_disable_after_days"`
 	} `mapstructure:"monitor"`
+	Links struct {
+		ExpiredRedirectURL string `mapstructure:"expired_redirect_url"`
+		DownFallbackURL    string `mapstructure:"down_fallback_url"`
+		DownStatusPage     bool   `mapstructure:"down_status_page"`
+	} `mapstructure:"links"`
+	URLPolicy URLPolicyConfig `mapstructure:"url_policy"`
+}
+
+// URLPolicyConfig décrit la politique appliquée aux destinations des liens (section url_policy) :
+// traitement des adresses privées à la création et garde du client HTTP du moniteur.
+type URLPolicyConfig struct {
+	PrivateDestinations string   `mapstructure:"private_destinations"` // reject, flag ou allow
+	AllowedHosts        []string `mapstructure:"allowed_hosts"`        // Domaines internes de confiance (sous-domaines compris)
+	AllowedCIDRs        []string `mapstructure:"allowed_cidrs"`        // Plages d'adresses internes de confiance
+}
+
+// NotifierConfig décrit un canal de notification des changements d'état détectés par le moniteur
+// (entrée de monitor.notifiers).
+type NotifierConfig struct {
+	Type           string   `mapstructure:"type"`            // log ou webhook
+	URL            string   `mapstructure:"url"`             // URL du webhook
+	Secret         string   `mapstructure:"secret"`          // Secret de la signature HMAC-SHA256 du webhook
+	Links          []string `mapstructure:"links"`           // Codes courts suivis (vide = tous les liens)
+	MaxRetries     *int     `mapstructure:"max_retries"`     // Nouvelles tentatives du webhook (défaut: 3)
+	TimeoutSeconds int      `mapstructure:"timeout_seconds"` // Délai de chaque tentative (défaut: 5)
 }
 
 // LoadConfig charge la configuration de l'application en utilisant Viper.
@@ -47,10 +107,44 @@ func LoadConfig() (*Config, error) {
 	// server.port, server.base_url etc.
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.base_url", "http://localhost:8080/")
+	viper.SetDefault("server.shutdown_timeout_seconds", 15)
 	viper.SetDefault("database.name", "url_shortener.db")
 	viper.SetDefault("analytics.buffer_size", 1000)
 	viper.SetDefault("analytics.worker_count", 5)
+	viper.SetDefault("analytics.batch_size", 1)
+	viper.SetDefault("analytics.batch_flush_interval_ms", 200)
+	viper.SetDefault("analytics.bot_ip_ranges", []string{})
+	viper.SetDefault("analytics.privacy.ip_mode", "full")
+	viper.SetDefault("analytics.privacy.ipv4_prefix_length", 24)
+	viper.SetDefault("analytics.privacy.ipv6_prefix_length", 48)
+	viper.SetDefault("analytics.privacy.retention_days", 0)
+	viper.SetDefault("analytics.privacy.retention_mode", "delete")
+	viper.SetDefault("analytics.privacy.purge_interval_hours", 24)
+	viper.SetDefault("analytics.spool.enabled", false)
+	viper.SetDefault("analytics.spool.dir", "click_spool")
+	viper.SetDefault("analytics.spool.max_segment_mb", 8)
+	viper.SetDefault("analytics.spool.max_total_mb", 512)
+	viper.SetDefault("analytics.spool.sync_writes", false)
 	viper.SetDefault("monitor.interval_minutes", 10)
+	viper.SetDefault("monitor.history_days", 30)
+	viper.SetDefault("monitor.concurrency", 10)
+	viper.SetDefault("monitor.per_host_concurrency", 2)
+	viper.SetDefault("monitor.per_host_requests_per_second", 1.0)
+	viper.SetDefault("monitor.request_timeout_seconds", 5)
+	viper.SetDefault("monitor.accepted_status_codes", "200-399")
+	viper.SetDefault("monitor.failure_threshold", 2)
+	viper.SetDefault("monitor.user_agent", "urlshortener-monitor/1.0")
+	viper.SetDefault("monitor.get_fallback", true)
+	viper.SetDefault("monitor.get_fallback_max_bytes", 4096)
+	viper.SetDefault("monitor.tls_expiry_warning_days", 14)
+	viper.SetDefault("monitor.content_fingerprint", false)
+	viper.SetDefault("monitor.content_max_bytes", 65536)
+	viper.SetDefault("monitor.content_change_bits", 10)
+	viper.SetDefault("monitor.auto_disable_after_days", 0)
+	viper.SetDefault("links.expired_redirect_url", "")
+	viper.SetDefault("links.down_fallback_url", "")
+	viper.SetDefault("links.down_status_page", false)
+	viper.SetDefault("url_policy.private_destinations", "reject")
 
 	// Lit le fichier de configuration.
 	err := viper.ReadInConfig()
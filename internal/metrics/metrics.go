@@ -0,0 +1,55 @@
+package metrics
+
+// Default est le registre exposé par la route /metrics.
+var Default = NewRegistry()
+
+// DefBuckets sont les bornes (en secondes) des histogrammes de latence.
+var DefBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Métriques de l'application. Les noms suivent les conventions Prometheus (préfixe commun,
+// suffixe _total pour les compteurs, durées en secondes).
+var (
+	// Redirections
+	Redirects = NewCounterVec(Default, "urlshortener_redirects_total",
+		"Redirect requests handled, by HTTP status code.", "status")
+	RedirectDuration = NewHistogramVec(Default, "urlshortener_redirect_duration_seconds",
+		"Redirect request latency in seconds, by HTTP status code.", DefBuckets, "status")
+	RedirectFallbacks = NewCounterVec(Default, "urlshortener_redirect_fallbacks_total",
+		"Redirects diverted because the destination is down, by target (fallback_url or status_page).", "target")
+
+	// Liens
+	LinksCreated = NewCounterVec(Default, "urlshortener_links_created_total",
+		"Short links created through the API.")
+
+	// Pipeline des clics
+	ClickQueueDepth = NewGauge(Default, "urlshortener_click_queue_depth",
+		"Click events waiting in the in-memory channel.")
+	ClickSpoolBacklogBytes = NewGauge(Default, "urlshortener_click_spool_backlog_bytes",
+		"Bytes of unacknowledged click events in the durable spool.")
+	ClickEventsDropped = NewCounterVec(Default, "urlshortener_click_events_dropped_total",
+		"Click events dropped because the channel or the spool was full.")
+	ClicksStored = NewCounterVec(Default, "urlshortener_clicks_stored_total",
+		"Clicks persisted to the database by the workers.")
+	ClickInsertDuration = NewHistogramVec(Default, "urlshortener_click_insert_duration_seconds",
+		"Latency of click insert attempts in seconds, by mode (single or batch).", DefBuckets, "mode")
+	ClickInsertRetries = NewCounterVec(Default, "urlshortener_click_insert_retries_total",
+		"Click insert attempts retried after an error.")
+	ClickInsertFailures = NewCounterVec(Default, "urlshortener_click_insert_failures_total",
+		"Click inserts abandoned after all retries, by mode.", "mode")
+
+	// Moniteur d'URLs
+	MonitorChecks = NewCounterVec(Default, "urlshortener_monitor_checks_total",
+		"URL checks performed by the monitor, by result (up or down).", "result")
+	MonitorCheckDuration = NewHistogramVec(Default, "urlshortener_monitor_check_duration_seconds",
+		"Latency of a single URL check in seconds.", DefBuckets)
+	MonitorStateChanges = NewCounterVec(Default, "urlshortener_monitor_state_changes_total",
+		"Links whose accessibility changed between two checks, by new state.", "state")
+	MonitorContentChanges = NewCounterVec(Default, "urlshortener_monitor_content_changes_total",
+		"Destination pages whose content changed significantly since the last fingerprint.")
+	MonitorAutoDisables = NewCounterVec(Default, "urlshortener_monitor_auto_disables_total",
+		"Links disabled or re-enabled by the monitor, by action (disabled or reenabled).", "action")
+	MonitorCyclesSkipped = NewCounterVec(Default, "urlshortener_monitor_cycles_skipped_total",
+		"Monitor cycles skipped because the previous one was still running.")
+	MonitorLastRun = NewGauge(Default, "urlshortener_monitor_last_run_timestamp_seconds",
+		"Unix time of the end of the last complete monitor run.")
+)
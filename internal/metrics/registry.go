@@ -0,0 +1,285 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector est implémenté par chaque type de métrique pour s'écrire au format texte Prometheus.
+type collector interface {
+	writeText(w *bufio.Writer)
+}
+
+// Registry regroupe des métriques et les expose au format texte Prometheus (version 0.0.4),
+// sans dépendance à la bibliothèque cliente ni à un serveur Prometheus.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry crée un registre vide.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText écrit toutes les métriques du registre, dans leur ordre d'enregistrement.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.writeText(bw)
+	}
+	return bw.Flush()
+}
+
+// series est une valeur associée à une combinaison de valeurs de labels.
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+// vec stocke les séries d'une métrique, indexées par leurs valeurs de labels.
+type vec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{name: name, help: help, labels: labels, series: make(map[string]*series)}
+}
+
+// get retourne la série correspondant aux valeurs de labels ; mu doit être verrouillé.
+func (v *vec) get(labelValues []string) *series {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label value(s), got %d", v.name, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sortedKeys retourne les clés des séries triées, pour une sortie stable ; mu doit être verrouillé.
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec) writeHeader(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, kind)
+}
+
+// CounterVec est un compteur monotone, éventuellement découpé par labels.
+type CounterVec struct {
+	vec
+}
+
+// NewCounterVec crée et enregistre un compteur. Sans label, la série unique est exposée dès le départ (à 0).
+func NewCounterVec(r *Registry, name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, labels)}
+	if len(labels) == 0 {
+		c.get(nil)
+	}
+	r.register(c)
+	return c
+}
+
+// Inc incrémente de 1 la série correspondant aux valeurs de labels.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add ajoute 'delta' (positif) à la série correspondant aux valeurs de labels.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.get(labelValues).value += delta
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) writeText(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w, "counter")
+	for _, key := range c.sortedKeys() {
+		s := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, s.labelValues, "", ""), formatFloat(s.value))
+	}
+}
+
+// Gauge est une valeur instantanée, fixée par Set ou lue à chaque exposition via SetFunc.
+type Gauge struct {
+	name string
+	help string
+
+	mu    sync.Mutex
+	value float64
+	fn    func() float64
+}
+
+// NewGauge crée et enregistre une jauge.
+func NewGauge(r *Registry, name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	r.register(g)
+	return g
+}
+
+// Set fixe la valeur de la jauge.
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	g.value = value
+	g.fn = nil
+	g.mu.Unlock()
+}
+
+// SetFunc fait calculer la valeur de la jauge par 'fn' à chaque exposition (ex: profondeur d'un channel).
+func (g *Gauge) SetFunc(fn func() float64) {
+	g.mu.Lock()
+	g.fn = fn
+	g.mu.Unlock()
+}
+
+func (g *Gauge) writeText(w *bufio.Writer) {
+	g.mu.Lock()
+	value, fn := g.value, g.fn
+	g.mu.Unlock()
+	if fn != nil {
+		value = fn()
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", g.name, escapeHelp(g.help))
+	fmt.Fprintf(w, "# TYPE %s gauge\n", g.name)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(value))
+}
+
+// HistogramVec répartit des observations (ex: des durées en secondes) dans des buckets cumulatifs.
+type HistogramVec struct {
+	vec
+	buckets []float64
+
+	counts map[string][]uint64 // observations par bucket (non cumulées), indexées comme vec.series
+	sums   map[string]float64
+}
+
+// NewHistogramVec crée et enregistre un histogramme avec les bornes 'buckets' (triées, sans +Inf).
+func NewHistogramVec(r *Registry, name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		vec:     newVec(name, help, labels),
+		buckets: append([]float64(nil), buckets...),
+		counts:  make(map[string][]uint64),
+		sums:    make(map[string]float64),
+	}
+	sort.Float64s(h.buckets)
+	if len(labels) == 0 {
+		h.get(nil)
+		h.counts[""] = make([]uint64, len(h.buckets)+1)
+	}
+	r.register(h)
+	return h
+}
+
+// Observe enregistre une observation pour les valeurs de labels données.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(labelValues)
+	key := strings.Join(s.labelValues, "\xff")
+	counts, ok := h.counts[key]
+	if !ok {
+		counts = make([]uint64, len(h.buckets)+1) // dernier élément : au-delà de la plus grande borne
+		h.counts[key] = counts
+	}
+	i := sort.SearchFloat64s(h.buckets, value)
+	counts[i]++
+	h.sums[key] += value
+	s.value++
+}
+
+func (h *HistogramVec) writeText(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w, "histogram")
+	for _, key := range h.sortedKeys() {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += h.counts[key][i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.labelValues, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %s\n", h.name, formatLabels(h.labels, s.labelValues, "le", "+Inf"), formatFloat(s.value))
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s.labelValues, "", ""), formatFloat(h.sums[key]))
+		fmt.Fprintf(w, "%s_count%s %s\n", h.name, formatLabels(h.labels, s.labelValues, "", ""), formatFloat(s.value))
+	}
+}
+
+// formatLabels construit le bloc {a="x",b="y"} ; 'extraName' ajoute un label supplémentaire (le "le" des buckets).
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", name, escapeLabelValue(values[i]))
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", extraName, extraValue)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string { return labelValueEscaper.Replace(v) }
+
+func escapeHelp(v string) string { return helpEscaper.Replace(v) }
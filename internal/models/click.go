@@ -5,17 +5,55 @@ import "time"
 // Click représente un événement de clic sur un lien raccourci.
 // GORM utilisera ces tags pour créer la table 'clicks'.
 type Click struct {
-	ID        uint      `gorm:"primaryKey"`        // Clé primaire
-	LinkID    uint      `gorm:"index"`             // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
-	Link      Link      `gorm:"foreignKey:LinkID"` // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
-	Timestamp time.Time // Horodatage précis du clic
-	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué
-	IP string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+	ID             uint      `gorm:"primaryKey"`        // Clé primaire
+	LinkID         uint      `gorm:"index"`             // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
+	Link           Link      `gorm:"foreignKey:LinkID"` // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
+	Timestamp      time.Time // Horodatage précis du clic
+	UserAgent      string    `gorm:"size:255"`                     // User-Agent de l'utilisateur qui a cliqué
+	IP             string    `gorm:"size:50"`                      // Adresse IP de l'utilisateur
+	Referrer       string    `gorm:"size:255"`                     // Valeur brute de l'en-tête Referer
+	ReferrerDomain string    `gorm:"size:255;index"`               // Domaine référent normalisé (vide = accès direct)
+	BrowserFamily  string    `gorm:"size:50;index"`                // Famille du navigateur déduite du User-Agent (ex: Firefox)
+	BrowserVersion string    `gorm:"size:20"`                      // Version majeure du navigateur
+	OS             string    `gorm:"size:50;index"`                // Système d'exploitation déduit du User-Agent
+	DeviceType     string    `gorm:"size:20;index"`                // desktop, mobile, tablet ou bot
+	IsBot          bool      `gorm:"not null;default:false;index"` // Clic attribué à un robot, exclu des statistiques par défaut
+	VisitorHash    string    `gorm:"size:64;index"`                // Hash anonyme IP + User-Agent, salé et renouvelé chaque jour
 }
 
 type ClickEvent struct {
 	LinkID    uint      // ID du lien ajouté par l'utilisateur
 	Timestamp time.Time // Heure de l'event
-	UserAgent string    // Referrer du navigateur
-	IP string    // Adresse IP de l'utilisateur
+	UserAgent string    // User-Agent du navigateur
+	IP        string    // Adresse IP de l'utilisateur
+	Referrer  string    // Referrer du navigateur (en-tête Referer)
+	Method    string    // Méthode HTTP de la requête (GET, HEAD...)
+
+	// Renseignés par analytics.Enricher.Anonymize avant l'écriture de l'événement dans le spool :
+	// IP contient alors la forme anonymisée, et les calculs qui ont besoin de l'IP complète
+	// (détection des robots, hash de visiteur) sont déjà faits.
+	Anonymized  bool   `json:",omitempty"`
+	IsBot       bool   `json:",omitempty"`
+	VisitorHash string `json:",omitempty"`
+
+	// Ack est appelée une fois l'événement enregistré (ou écarté car invalide) par un worker.
+	// Nack est appelée lorsque l'enregistrement a échoué : le spool relivrera l'événement.
+	// Elles sont renseignées par le spool durable et ne sont jamais sérialisées ; nil hors spool.
+	Ack  func() `json:"-"`
+	Nack func() `json:"-"`
+}
+
+// Acknowledge signale que l'événement a été traité. Sans effet si l'événement ne provient pas du spool.
+func (e ClickEvent) Acknowledge() {
+	if e.Ack != nil {
+		e.Ack()
+	}
+}
+
+// Retry signale que l'événement n'a pas pu être enregistré. Le spool le relivre plus tard (et,
+// à défaut, le rejoue au prochain démarrage) ; hors spool, l'événement est perdu.
+func (e ClickEvent) Retry() {
+	if e.Nack != nil {
+		e.Nack()
+	}
 }
@@ -0,0 +1,14 @@
+package models
+
+// ClickDailyAggregate conserve le nombre de clics d'un lien pour un jour (UTC) donné une fois
+// les clics bruts de ce jour supprimés par la politique de rétention (mode "aggregate").
+// GORM utilisera ces tags pour créer la table 'click_daily_aggregates'.
+type ClickDailyAggregate struct {
+	ID             uint   `gorm:"primaryKey"`
+	LinkID         uint   `gorm:"not null;uniqueIndex:idx_click_aggregate_link_day"`
+	Day            string `gorm:"size:10;not null;uniqueIndex:idx_click_aggregate_link_day"` // Date au format YYYY-MM-DD (UTC)
+	Clicks         int64  `gorm:"not null;default:0"`                                        // Tous les clics, robots inclus
+	HumanClicks    int64  `gorm:"not null;default:0"`                                        // Clics hors robots
+	UniqueVisitors int64  `gorm:"not null;default:0"`                                        // Hash de visiteurs distincts du jour (hors robots)
+	Visitors       int64  `gorm:"not null;default:0"`                                        // Hash de visiteurs distincts du jour, robots compris
+}
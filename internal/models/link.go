@@ -1,15 +1,75 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 // Link représente un lien raccourci dans la base de données.
 // Les tags `gorm:"..."` définissent comment GORM doit mapper cette structure à une table SQL.
 // ID qui est une primaryKey
 // Shortcode : doit être unique, indexé pour des recherches rapide (voir doc), taille max 10 caractères
 // LongURL : doit pas être null
 // CreateAt : Horodatage de la créatino du lien
+// ExpiresAt : Date d'expiration optionnelle (nil = le lien n'expire jamais)
+// MaxClicks : Budget de clics optionnel (0 = illimité)
+// UsedClicks : Nombre de redirections déjà consommées sur le budget, incrémenté atomiquement
+// Disabled : Lien désactivé, il ne redirige plus mais reste consultable
+// DisabledAt, DisabledReason : Date et motif de la désactivation
+// AutoDisabled : Lien désactivé par le moniteur (destination morte), réactivé automatiquement à son rétablissement
+// ReenabledAt : Date de la dernière réactivation manuelle ; le moniteur ne compte la durée d'échec qu'à partir de cette date
+// LongURLChangedAt : Date du dernier changement de LongURL ; le moniteur ignore ce qu'il a retenu de l'ancienne destination
+// DeletedAt : Suppression logique (soft delete) gérée par GORM, le lien peut être restauré
+// FallbackURL : URL de repli optionnelle, utilisée tant que le moniteur juge LongURL inaccessible
+// PrivateDestination : LongURL désigne un réseau privé (url_policy.private_destinations = "flag")
+// Monitor : Réglages de surveillance propres au lien (colonnes monitor_*), vides = réglages globaux
 
 type Link struct {
-	ID        uint   `gorm:"primaryKey"`
-	ShortCode string `gorm:"size:10;uniqueIndex;not null"`
-	LongURL   string `gorm:"not null"`
-	CreatedAt int64  `gorm:"autoCreateTime"`
-}
\ No newline at end of file
+	ID                 uint       `gorm:"primaryKey"`
+	ShortCode          string     `gorm:"size:10;uniqueIndex;not null"`
+	LongURL            string     `gorm:"not null"`
+	CreatedAt          int64      `gorm:"autoCreateTime"`
+	ExpiresAt          *time.Time `gorm:"index"`
+	MaxClicks          int        `gorm:"not null;default:0"`
+	UsedClicks         int        `gorm:"not null;default:0"`
+	Disabled           bool       `gorm:"not null;default:false"`
+	DisabledAt         *time.Time
+	DisabledReason     string `gorm:"size:255"`
+	AutoDisabled       bool   `gorm:"not null;default:false"`
+	ReenabledAt        *time.Time
+	LongURLChangedAt   *time.Time
+	DeletedAt          gorm.DeletedAt      `gorm:"index"`
+	FallbackURL        string              `gorm:"size:2048"`
+	PrivateDestination bool                `gorm:"not null;default:false"`
+	Monitor            LinkMonitorSettings `gorm:"embedded;embeddedPrefix:monitor_"`
+}
+
+// Statuts possibles d'un lien, calculés à partir de ses champs (voir Link.Status).
+const (
+	LinkStatusActive   = "active"
+	LinkStatusDisabled = "disabled"
+	LinkStatusExpired  = "expired"
+	LinkStatusDeleted  = "deleted"
+)
+
+// Status retourne le statut du lien à l'instant donné. Un lien supprimé est "deleted",
+// puis un lien désactivé est "disabled", puis un lien expiré ou dont le budget de clics
+// est épuisé est "expired" ; sinon il est "active".
+func (l *Link) Status(now time.Time) string {
+	switch {
+	case l.DeletedAt.Valid:
+		return LinkStatusDeleted
+	case l.Disabled:
+		return LinkStatusDisabled
+	case l.IsExpired(now) || (l.MaxClicks > 0 && l.UsedClicks >= l.MaxClicks):
+		return LinkStatusExpired
+	default:
+		return LinkStatusActive
+	}
+}
+
+// IsExpired indique si la date d'expiration du lien est dépassée à l'instant donné.
+func (l *Link) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// Classes d'erreur d'une vérification du moniteur (LinkCheck.ErrorClass).
+const (
+	CheckErrorTimeout    = "timeout"            // Délai de réponse dépassé
+	CheckErrorDNS        = "dns"                // Nom d'hôte introuvable
+	CheckErrorConnection = "connection_refused" // Connexion refusée ou réinitialisée
+	CheckErrorTLS        = "tls"                // Échec de la négociation TLS / certificat invalide
+	CheckErrorHTTPStatus = "http_status"        // Réponse reçue avec un statut d'erreur (4xx/5xx)
+	CheckErrorInvalidURL = "invalid_url"        // URL impossible à requêter
+	CheckErrorRedirect   = "redirect"           // Boucle de redirections ou trop de redirections
+	CheckErrorBlocked    = "blocked"            // Destination privée refusée par la politique d'URL (url_policy)
+	CheckErrorOther      = "other"              // Toute autre erreur réseau
+)
+
+// États d'un lien retenus par le moniteur après application du seuil d'échecs (LinkCheck.State).
+const (
+	CheckStateUp   = "up"
+	CheckStateDown = "down"
+)
+
+// RedirectHop est une étape de la chaîne de redirections suivie lors d'une vérification :
+// l'URL requêtée et le statut de redirection qu'elle a renvoyé.
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// LinkCheck représente le résultat d'une vérification de l'URL longue d'un lien par le moniteur.
+// GORM utilisera ces tags pour créer la table 'link_checks'.
+type LinkCheck struct {
+	ID         uint      `gorm:"primaryKey"`
+	LinkID     uint      `gorm:"not null;index:idx_link_checks_link_time"`
+	CheckedAt  time.Time `gorm:"not null;index:idx_link_checks_link_time;index"`
+	Accessible bool      `gorm:"not null"`
+	StatusCode int       // Statut HTTP de la réponse finale (0 si aucune réponse)
+	LatencyMs  int64     // Durée de la vérification, redirections comprises
+	ErrorClass string    `gorm:"size:30"`   // Vide si l'URL est accessible, sinon une des constantes CheckError*
+	FinalURL   string    `gorm:"size:2048"` // URL atteinte après les redirections
+	Method     string    `gorm:"size:10"`   // Méthode de la requête retenue (HEAD, ou GET en repli)
+	// Redirections suivies avant d'atteindre FinalURL, dans l'ordre (stockées en JSON)
+	RedirectChain []RedirectHop `gorm:"serializer:json"`
+	// Vrai si une redirection de la chaîne passe de https à http
+	HTTPSDowngrade bool
+	// Date d'expiration du certificat TLS présenté par FinalURL (nil hors https ou sans réponse)
+	TLSNotAfter *time.Time
+	// Empreinte du contenu, renseignée en mode empreinte (LinkMonitorSettings.ContentFingerprint) :
+	// SHA-256 et SimHash (hexadécimale) du texte normalisé, titre de la page, et indicateur
+	// d'un changement significatif par rapport à l'empreinte précédente du lien
+	ContentHash    string `gorm:"size:64"`
+	ContentSimHash string `gorm:"size:16"`
+	PageTitle      string `gorm:"size:255"`
+	ContentChanged bool
+	// Vrai si la vérification a eu lieu pendant une fenêtre de maintenance (notifications suspendues)
+	Maintenance bool
+	// Échecs consécutifs du lien, cette vérification comprise (0 si elle a réussi)
+	ConsecutiveFailures int
+	// Date du premier échec de la série en cours (nil si la vérification a réussi)
+	FailingSince *time.Time
+	// État retenu après cette vérification (CheckState*) : un échec isolé ne rend pas le lien
+	// inaccessible tant que le seuil d'échecs consécutifs n'est pas atteint. Vide pour les
+	// vérifications antérieures au seuil, l'état est alors déduit de Accessible.
+	State string `gorm:"size:10"`
+}
+
+// IsUp retourne l'état retenu par le moniteur après cette vérification.
+func (c *LinkCheck) IsUp() bool {
+	if c.State == "" {
+		return c.Accessible
+	}
+	return c.State == CheckStateUp
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LinkMonitorSettings regroupe les réglages de surveillance propres à un lien.
+// Chaque champ vide ou nil reprend la valeur globale de la configuration (section monitor).
+// Les colonnes sont préfixées par "monitor_" dans la table 'links'.
+type LinkMonitorSettings struct {
+	Enabled             *bool  // Surveillance du lien (nil = surveillé)
+	IntervalMinutes     *int   // Intervalle propre au lien, arrondi au cycle du moniteur
+	AcceptedStatusCodes string `gorm:"size:100"` // Statuts considérés comme accessibles, ex: "200-399,401" (voir ParseStatusCodes)
+	FailureThreshold    *int   // Échecs consécutifs avant de déclarer le lien inaccessible
+	UserAgent           string `gorm:"size:255"` // En-tête User-Agent des vérifications
+	GetFallback         *bool  // Réessayer en GET lorsque HEAD est refusé
+	ContentFingerprint  *bool  // Suivre l'empreinte du contenu pour détecter les changements de page
+}
+
+// IsZero indique si aucun réglage propre au lien n'est défini.
+func (s LinkMonitorSettings) IsZero() bool {
+	return s.Enabled == nil && s.IntervalMinutes == nil && s.AcceptedStatusCodes == "" && s.FailureThreshold == nil &&
+		s.UserAgent == "" && s.GetFallback == nil && s.ContentFingerprint == nil
+}
+
+// IsEnabled indique si le lien est surveillé par le moniteur.
+func (s LinkMonitorSettings) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// StatusCodes est un ensemble de statuts HTTP exprimé sous forme d'intervalles.
+type StatusCodes []StatusCodeRange
+
+// StatusCodeRange est un intervalle fermé de statuts HTTP.
+type StatusCodeRange struct {
+	Min, Max int
+}
+
+// ParseStatusCodes analyse une liste de statuts séparés par des virgules. Chaque élément est
+// un statut ("401"), un intervalle ("200-299") ou une classe ("2xx").
+func ParseStatusCodes(spec string) (StatusCodes, error) {
+	var codes StatusCodes
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+
+		var r StatusCodeRange
+		var err error
+		switch {
+		case len(part) == 3 && strings.HasSuffix(part, "xx"):
+			var class int
+			class, err = strconv.Atoi(part[:1])
+			r = StatusCodeRange{Min: class * 100, Max: class*100 + 99}
+		case strings.Contains(part, "-"):
+			low, high, _ := strings.Cut(part, "-")
+			r.Min, err = strconv.Atoi(strings.TrimSpace(low))
+			if err == nil {
+				r.Max, err = strconv.Atoi(strings.TrimSpace(high))
+			}
+		default:
+			r.Min, err = strconv.Atoi(part)
+			r.Max = r.Min
+		}
+		if err != nil || r.Min < 100 || r.Max > 599 || r.Min > r.Max {
+			return nil, fmt.Errorf("invalid status code %q", part)
+		}
+		codes = append(codes, r)
+	}
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no status code in %q", spec)
+	}
+	return codes, nil
+}
+
+// Contains indique si 'status' appartient à l'ensemble.
+func (c StatusCodes) Contains(status int) bool {
+	for _, r := range c {
+		if status >= r.Min && status <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// String retourne la forme canonique de l'ensemble, réutilisable par ParseStatusCodes.
+func (c StatusCodes) String() string {
+	parts := make([]string, len(c))
+	for i, r := range c {
+		if r.Min == r.Max {
+			parts[i] = strconv.Itoa(r.Min)
+		} else {
+			parts[i] = fmt.Sprintf("%d-%d", r.Min, r.Max)
+		}
+	}
+	return strings.Join(parts, ",")
+}
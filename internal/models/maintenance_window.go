@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// MaintenanceWindow est une période de maintenance déclarée pour un lien, ou pour tous les liens
+// lorsque LinkID est nil. Pendant la période, le moniteur continue de vérifier et d'historiser
+// les destinations, mais n'envoie aucune notification.
+// GORM utilisera ces tags pour créer la table 'maintenance_windows'.
+type MaintenanceWindow struct {
+	ID        uint      `gorm:"primaryKey"`
+	LinkID    *uint     `gorm:"index"` // nil = fenêtre globale
+	Link      *Link     // Lien concerné (chargé à la demande)
+	StartsAt  time.Time `gorm:"not null;index"`
+	EndsAt    time.Time `gorm:"not null;index"`
+	Reason    string    `gorm:"size:255"`
+	CreatedAt time.Time
+}
+
+// IsActive indique si la fenêtre couvre l'instant donné.
+func (w *MaintenanceWindow) IsActive(at time.Time) bool {
+	return !at.Before(w.StartsAt) && at.Before(w.EndsAt)
+}
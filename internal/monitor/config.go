@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
+)
+
+// OptionsFromConfig construit les Options décrites par la section monitor de la configuration.
+// Le Notifier n'est pas renseigné : il est propre au serveur (voir notify.NewDispatcherFromConfig).
+func OptionsFromConfig(cfg *config.Config) (Options, error) {
+	acceptedStatusCodes, err := models.ParseStatusCodes(cfg.Monitor.AcceptedStatusCodes)
+	if err != nil {
+		return Options{}, fmt.Errorf("monitor.accepted_status_codes: %w", err)
+	}
+	urlPolicy, err := urlpolicy.NewFromConfig(cfg.URLPolicy)
+	if err != nil {
+		return Options{}, err
+	}
+	return Options{
+		Interval:           time.Duration(cfg.Monitor.IntervalMinutes) * time.Minute,
+		HistoryDays:        cfg.Monitor.HistoryDays,
+		Concurrency:        cfg.Monitor.Concurrency,
+		PerHostConcurrency: cfg.Monitor.PerHostConcurrency,
+		PerHostRate:        cfg.Monitor.PerHostRequestsPerSecond,
+		RequestTimeout:     time.Duration(cfg.Monitor.RequestTimeoutSeconds) * time.Second,
+		TLSExpiryWarning:   time.Duration(cfg.Monitor.TLSExpiryWarningDays) * 24 * time.Hour,
+		ContentChangeBits:  cfg.Monitor.ContentChangeBits,
+		AutoDisableAfter:   time.Duration(cfg.Monitor.AutoDisableAfterDays) * 24 * time.Hour,
+		URLPolicy:          urlPolicy,
+		Policy: CheckPolicy{
+			AcceptedStatusCodes: acceptedStatusCodes,
+			FailureThreshold:    cfg.Monitor.FailureThreshold,
+			UserAgent:           cfg.Monitor.UserAgent,
+			GetFallback:         cfg.Monitor.GetFallback,
+			GetFallbackMaxBytes: cfg.Monitor.GetFallbackMaxBytes,
+			ContentFingerprint:  cfg.Monitor.ContentFingerprint,
+			ContentMaxBytes:     cfg.Monitor.ContentMaxBytes,
+		},
+	}, nil
+}
@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
+)
+
+// classifyError range une erreur de requête HTTP dans une des classes models.CheckError*.
+func classifyError(err error) string {
+	var (
+		dnsErr      *net.DNSError
+		netErr      net.Error
+		certErr     *tls.CertificateVerificationError
+		recordErr   tls.RecordHeaderError
+		unknownAuth x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		certInvalid x509.CertificateInvalidError
+		urlErr      *url.Error
+	)
+
+	switch {
+	case errors.Is(err, urlpolicy.ErrPrivateDestination):
+		return models.CheckErrorBlocked
+	case errors.Is(err, errRedirectLoop), errors.Is(err, errTooManyRedirects):
+		return models.CheckErrorRedirect
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.CheckErrorTimeout
+	case errors.As(err, &dnsErr):
+		return models.CheckErrorDNS
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET):
+		return models.CheckErrorConnection
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &unknownAuth),
+		errors.As(err, &hostnameErr), errors.As(err, &certInvalid):
+		return models.CheckErrorTLS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return models.CheckErrorTimeout
+	case errors.As(err, &urlErr) && strings.Contains(urlErr.Err.Error(), "unsupported protocol scheme"):
+		return models.CheckErrorInvalidURL
+	}
+	return models.CheckErrorOther
+}
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"html"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Taille maximale du titre de page conservé (colonne LinkCheck.PageTitle).
+const maxPageTitleLength = 255
+
+// defaultContentChangeBits est le seuil de changement de contenu par défaut : nombre de bits de
+// SimHash différents à partir duquel deux versions d'une page sont considérées différentes.
+const defaultContentChangeBits = 10
+
+// shingleSize est le nombre de mots consécutifs formant un élément de la SimHash.
+const shingleSize = 3
+
+var (
+	titlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	invisiblePattern = regexp.MustCompile(`(?is)<(script|style|noscript)[^>]*>.*?</(script|style|noscript)>|<!--.*?-->`)
+	tagPattern       = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// fingerprint est l'empreinte du contenu d'une page.
+type fingerprint struct {
+	hash    string // SHA-256 hexadécimal du texte normalisé (égalité stricte)
+	simHash uint64 // SimHash du texte normalisé (similarité)
+	title   string // Contenu de la balise <title>
+}
+
+// fingerprintContent calcule l'empreinte d'un extrait de page. Le texte est normalisé
+// (scripts, styles, commentaires et balises retirés, casse et espaces uniformisés) pour que
+// le balisage seul ne fasse pas varier l'empreinte.
+func fingerprintContent(body []byte) fingerprint {
+	text := normalizeText(string(body))
+	sum := sha256.Sum256([]byte(text))
+	return fingerprint{
+		hash:    hex.EncodeToString(sum[:]),
+		simHash: simHash(strings.Fields(text)),
+		title:   extractTitle(string(body)),
+	}
+}
+
+// extractTitle retourne le titre de la page, vide s'il n'y en a pas.
+func extractTitle(page string) string {
+	m := titlePattern.FindStringSubmatch(page)
+	if m == nil {
+		return ""
+	}
+	title := strings.Join(strings.Fields(html.UnescapeString(m[1])), " ")
+	for len(title) > maxPageTitleLength {
+		_, size := utf8.DecodeLastRuneInString(title)
+		title = title[:len(title)-size]
+	}
+	return title
+}
+
+// normalizeText extrait le texte visible d'une page.
+func normalizeText(page string) string {
+	page = invisiblePattern.ReplaceAllString(page, " ")
+	page = tagPattern.ReplaceAllString(page, " ")
+	page = html.UnescapeString(page)
+	return strings.Join(strings.Fields(strings.ToLower(page)), " ")
+}
+
+// simHash calcule la SimHash 64 bits des séquences de 'shingleSize' mots : deux textes proches
+// ont des SimHash qui diffèrent de peu de bits.
+func simHash(words []string) uint64 {
+	if len(words) == 0 {
+		return 0
+	}
+	var weights [64]int
+	for i := 0; i+shingleSize <= len(words) || i == 0; i++ {
+		end := i + shingleSize
+		if end > len(words) {
+			end = len(words)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:end], " ")))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var result uint64
+	for bit, w := range weights {
+		if w > 0 {
+			result |= 1 << bit
+		}
+	}
+	return result
+}
+
+// contentDistance retourne le nombre de bits différents entre deux SimHash.
+func contentDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// formatSimHash et parseSimHash convertissent une SimHash pour le stockage (hexadécimal).
+func formatSimHash(h uint64) string {
+	return strconv.FormatUint(h, 16)
+}
+
+func parseSimHash(s string) (uint64, bool) {
+	h, err := strconv.ParseUint(s, 16, 64)
+	return h, err == nil
+}
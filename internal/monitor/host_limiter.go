@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idleSweepInterval est l'intervalle minimal entre deux purges des hôtes inactifs.
+const idleSweepInterval = time.Minute
+
+// hostLimiter limite, pour chaque hôte de destination, le nombre de vérifications simultanées
+// et leur fréquence, afin de ne pas surcharger un même site hébergeant de nombreux liens.
+// L'état d'un hôte est oublié dès qu'il n'a plus de vérification en cours ni de délai à respecter.
+type hostLimiter struct {
+	concurrency int           // Vérifications simultanées maximales par hôte (<= 0 = illimité)
+	minInterval time.Duration // Délai minimal entre deux requêtes vers un même hôte (0 = aucun)
+
+	mu        sync.Mutex
+	hosts     map[string]*hostSlot
+	lastSweep time.Time // Date de la dernière purge des hôtes inactifs
+}
+
+// hostSlot est l'état du limiteur pour un hôte.
+type hostSlot struct {
+	sem  chan struct{}
+	next time.Time // Date au plus tôt de la prochaine requête
+	refs int       // Vérifications en cours ou en attente
+}
+
+func newHostLimiter(concurrency int, requestsPerSecond float64) *hostLimiter {
+	l := &hostLimiter{concurrency: concurrency, hosts: make(map[string]*hostSlot)}
+	if requestsPerSecond > 0 {
+		l.minInterval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+	return l
+}
+
+// slot retourne l'état de l'hôte en le réservant ; l'appelant doit appeler unref une fois terminé.
+func (l *hostLimiter) slot(host string) *hostSlot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= idleSweepInterval {
+		l.evictIdle(now)
+	}
+	s, ok := l.hosts[host]
+	if !ok {
+		s = &hostSlot{}
+		if l.concurrency > 0 {
+			s.sem = make(chan struct{}, l.concurrency)
+		}
+		l.hosts[host] = s
+	}
+	s.refs++
+	return s
+}
+
+func (l *hostLimiter) unref(s *hostSlot) {
+	l.mu.Lock()
+	s.refs--
+	l.mu.Unlock()
+}
+
+// evictIdle oublie les hôtes sans vérification en cours dont le délai minimal est écoulé.
+// Doit être appelée avec l.mu verrouillé.
+func (l *hostLimiter) evictIdle(now time.Time) {
+	for host, s := range l.hosts {
+		if s.refs == 0 && !s.next.After(now) {
+			delete(l.hosts, host)
+		}
+	}
+	l.lastSweep = now
+}
+
+// acquire attend qu'une vérification de 'host' soit autorisée et retourne la fonction à appeler
+// une fois la vérification terminée. Retourne une erreur si 'ctx' est annulé pendant l'attente.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	s := l.slot(strings.ToLower(host))
+
+	if s.sem != nil {
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			l.unref(s)
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if s.sem != nil {
+			<-s.sem
+		}
+		l.unref(s)
+	}
+
+	if err := l.pace(ctx, s); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// wait respecte le délai minimal avant une requête supplémentaire vers 'host' au cours d'une
+// vérification déjà autorisée par acquire (repli en GET, par exemple).
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	s := l.slot(strings.ToLower(host))
+	defer l.unref(s)
+	return l.pace(ctx, s)
+}
+
+// pace réserve le prochain créneau de requête de l'hôte et attend son début.
+func (l *hostLimiter) pace(ctx context.Context, s *hostSlot) error {
+	if l.minInterval <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	start := s.next
+	if start.Before(now) {
+		start = now
+	}
+	s.next = start.Add(l.minInterval)
+	l.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
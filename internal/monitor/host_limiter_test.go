@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterEvictsIdleHosts(t *testing.T) {
+	l := newHostLimiter(1, 0)
+	for _, host := range []string{"a.example", "b.example", "c.example"} {
+		release, err := l.acquire(context.Background(), host)
+		if err != nil {
+			t.Fatalf("acquire(%s): %v", host, err)
+		}
+		if host != "c.example" {
+			release()
+		}
+	}
+
+	l.mu.Lock()
+	l.evictIdle(time.Now())
+	_, busy := l.hosts["c.example"]
+	remaining := len(l.hosts)
+	l.mu.Unlock()
+	if !busy || remaining != 1 {
+		t.Fatalf("%d host(s) kept after eviction (busy kept: %v), want only the busy one", remaining, busy)
+	}
+}
+
+func TestHostLimiterKeepsPendingInterval(t *testing.T) {
+	l := newHostLimiter(0, 10) // Une requête toutes les 100 ms
+	release, err := l.acquire(context.Background(), "a.example")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	release()
+
+	// L'hôte n'a plus de vérification en cours mais son délai court encore : il est conservé
+	l.mu.Lock()
+	l.evictIdle(time.Now())
+	_, kept := l.hosts["a.example"]
+	l.mu.Unlock()
+	if !kept {
+		t.Fatal("host evicted before its minimal interval elapsed")
+	}
+}
+
+func TestHostLimiterWaitSpacesFallbackRequest(t *testing.T) {
+	l := newHostLimiter(1, 10) // Une requête toutes les 100 ms
+	release, err := l.acquire(context.Background(), "a.example")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer release()
+
+	start := time.Now()
+	if err := l.wait(context.Background(), "A.example"); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("second request after %v, want at least the minimal interval", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, "a.example"); err == nil {
+		t.Fatal("wait succeeded with a cancelled context")
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Valeurs par défaut de CheckPolicy.
+const (
+	DefaultAcceptedStatusCodes = "200-399"
+	DefaultUserAgent           = "urlshortener-monitor/1.0"
+	defaultGetFallbackMaxBytes = 4 << 10
+	defaultContentMaxBytes     = 64 << 10
+)
+
+// CheckPolicy décrit comment vérifier une URL et interpréter le résultat. La politique globale
+// (Options.Policy) est complétée, lien par lien, par les réglages models.LinkMonitorSettings.
+type CheckPolicy struct {
+	AcceptedStatusCodes models.StatusCodes // Statuts considérés comme accessibles (défaut: 200-399)
+	FailureThreshold    int                // Échecs consécutifs avant de déclarer un lien inaccessible (défaut: 1)
+	UserAgent           string             // En-tête User-Agent des requêtes (défaut: DefaultUserAgent)
+	GetFallback         bool               // Réessayer en GET lorsque HEAD échoue ou renvoie un statut refusé
+	GetFallbackMaxBytes int64              // Octets du corps lus au plus lors d'un repli en GET (défaut: 4 Kio)
+	ContentFingerprint  bool               // Vérifier en GET et calculer l'empreinte du contenu de la page
+	ContentMaxBytes     int64              // Octets du corps lus au plus pour l'empreinte (défaut: 64 Kio)
+}
+
+// withDefaults complète les champs non renseignés de la politique.
+func (p CheckPolicy) withDefaults() CheckPolicy {
+	if len(p.AcceptedStatusCodes) == 0 {
+		p.AcceptedStatusCodes, _ = models.ParseStatusCodes(DefaultAcceptedStatusCodes)
+	}
+	if p.FailureThreshold < 1 {
+		p.FailureThreshold = 1
+	}
+	if p.UserAgent == "" {
+		p.UserAgent = DefaultUserAgent
+	}
+	if p.GetFallbackMaxBytes <= 0 {
+		p.GetFallbackMaxBytes = defaultGetFallbackMaxBytes
+	}
+	if p.ContentMaxBytes <= 0 {
+		p.ContentMaxBytes = defaultContentMaxBytes
+	}
+	return p
+}
+
+// policyFor retourne la politique effective d'un lien : la politique globale surchargée par
+// les réglages propres au lien. Des statuts acceptés invalides en base sont ignorés.
+func (m *UrlMonitor) policyFor(link models.Link) CheckPolicy {
+	p := m.policy
+	s := link.Monitor
+	if s.AcceptedStatusCodes != "" {
+		codes, err := models.ParseStatusCodes(s.AcceptedStatusCodes)
+		if err != nil {
+			log.Printf("[MONITOR] Statuts acceptés invalides pour le lien %s, réglage global utilisé : %v", link.ShortCode, err)
+		} else {
+			p.AcceptedStatusCodes = codes
+		}
+	}
+	if s.FailureThreshold != nil && *s.FailureThreshold > 0 {
+		p.FailureThreshold = *s.FailureThreshold
+	}
+	if s.UserAgent != "" {
+		p.UserAgent = s.UserAgent
+	}
+	if s.GetFallback != nil {
+		p.GetFallback = *s.GetFallback
+	}
+	if s.ContentFingerprint != nil {
+		p.ContentFingerprint = *s.ContentFingerprint
+	}
+	return p
+}
+
+// requestKey identifie les vérifications interchangeables : deux liens vers la même URL
+// partagent une requête tant que leurs politiques produisent la même requête et le même verdict.
+// Le seuil d'échecs n'en fait pas partie, il est appliqué lien par lien.
+func (p CheckPolicy) requestKey(rawURL string) string {
+	return strings.Join([]string{
+		rawURL,
+		p.AcceptedStatusCodes.String(),
+		p.UserAgent,
+		strconv.FormatBool(p.GetFallback),
+		strconv.FormatBool(p.ContentFingerprint),
+	}, "\x00")
+}
@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// maxRedirects est le nombre maximal de redirections suivies par une vérification.
+const maxRedirects = 10
+
+// Erreurs renvoyées par checkRedirect, classées en models.CheckErrorRedirect.
+var (
+	errRedirectLoop     = errors.New("redirect loop")
+	errTooManyRedirects = errors.New("too many redirects")
+)
+
+// redirectTrace accumule les redirections suivies par une requête. Il est transmis au client
+// HTTP partagé via le contexte de la requête (voir withRedirectTrace).
+type redirectTrace struct {
+	hops []models.RedirectHop
+}
+
+type redirectTraceKey struct{}
+
+// withRedirectTrace retourne un contexte dans lequel checkRedirect enregistre les redirections.
+func withRedirectTrace(ctx context.Context) (context.Context, *redirectTrace) {
+	trace := &redirectTrace{}
+	return context.WithValue(ctx, redirectTraceKey{}, trace), trace
+}
+
+// checkRedirect est la politique de redirection du client du moniteur : chaque redirection est
+// enregistrée dans la trace de la requête, et la chaîne est interrompue si elle revient sur une
+// URL déjà visitée ou dépasse maxRedirects.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if trace, ok := req.Context().Value(redirectTraceKey{}).(*redirectTrace); ok && req.Response != nil {
+		trace.hops = append(trace.hops, models.RedirectHop{
+			URL:        via[len(via)-1].URL.String(),
+			StatusCode: req.Response.StatusCode,
+		})
+	}
+
+	next := req.URL.String()
+	for _, previous := range via {
+		if previous.URL.String() == next {
+			return errRedirectLoop
+		}
+	}
+	if len(via) >= maxRedirects {
+		return errTooManyRedirects
+	}
+	return nil
+}
+
+// isHTTPSDowngrade indique si une étape de la chaîne (redirections puis URL finale, si elle
+// est connue) passe d'une URL https à une URL http.
+func isHTTPSDowngrade(hops []models.RedirectHop, finalURL string) bool {
+	urls := make([]string, 0, len(hops)+1)
+	for _, hop := range hops {
+		urls = append(urls, hop.URL)
+	}
+	if finalURL != "" {
+		urls = append(urls, finalURL)
+	}
+	for i := 1; i < len(urls); i++ {
+		if strings.HasPrefix(urls[i-1], "https://") && strings.HasPrefix(urls[i], "http://") {
+			return true
+		}
+	}
+	return false
+}
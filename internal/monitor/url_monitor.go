@@ -1,52 +1,245 @@
 package monitor
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
 	"sync" // Pour protéger l'accès concurrentiel à knownStates
+	"sync/atomic"
 	"time"
 
-	_ "github.com/axellelanca/urlshortener/internal/models"   // Importe les modèles de liens
-	"github.com/axellelanca/urlshortener/internal/repository" // Importe le repository de liens
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"     // Importe les modèles de liens et de vérifications
+	"github.com/axellelanca/urlshortener/internal/notify"     // Distribution des notifications
+	"github.com/axellelanca/urlshortener/internal/repository" // Importe les repositories de liens et de vérifications
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"  // Contrôle des adresses contactées
 )
 
+// Options configure un UrlMonitor.
+type Options struct {
+	Interval           time.Duration      // Intervalle entre chaque vérification (ex: 5 minutes)
+	HistoryDays        int                // Durée de conservation de l'historique des vérifications (0 = illimitée)
+	Notifier           *notify.Dispatcher // Destinataires des changements d'état (nil = aucun)
+	Concurrency        int                // Nombre de vérifications simultanées (défaut: 1)
+	PerHostConcurrency int                // Vérifications simultanées maximales vers un même hôte (0 = illimité)
+	PerHostRate        float64            // Vérifications par seconde maximales vers un même hôte (0 = illimité)
+	RequestTimeout     time.Duration      // Délai maximal d'une vérification (défaut: 5s)
+	Policy             CheckPolicy        // Politique de vérification globale, surchargeable par lien
+	TLSExpiryWarning   time.Duration      // Préavis d'expiration des certificats TLS (0 = aucun avertissement)
+	ContentChangeBits  int                // Bits de SimHash différents à partir desquels un contenu a changé (défaut: 10)
+	AutoDisableAfter   time.Duration      // Durée d'échec continu avant de désactiver un lien (0 = jamais)
+	URLPolicy          *urlpolicy.Policy  // Refus des adresses privées à la connexion (nil = aucun contrôle)
+}
+
 // UrlMonitor gère la surveillance périodique des URLs longues.
 type UrlMonitor struct {
-	linkRepo    repository.LinkRepository // Pour récupérer les URLs à surveiller
-	interval    time.Duration             // Intervalle entre chaque vérification (ex: 5 minutes)
-	knownStates map[uint]bool             // État connu de chaque URL: map[LinkID]estAccessible (true/false)
-	mu          sync.Mutex                // Mutex pour protéger l'accès concurrentiel à knownStates
+	linkRepo    repository.LinkRepository              // Pour récupérer les URLs à surveiller
+	checkRepo   repository.LinkCheckRepository         // Pour historiser chaque vérification
+	windowRepo  repository.MaintenanceWindowRepository // Fenêtres de maintenance (notifications suspendues)
+	interval    time.Duration                          // Intervalle entre chaque vérification (ex: 5 minutes)
+	historyDays int                                    // Durée de conservation de l'historique des vérifications (0 = illimitée)
+	notifier    *notify.Dispatcher                     // Destinataires des changements d'état
+	concurrency int                                    // Taille du pool de vérification
+	limiter     *hostLimiter                           // Limites de concurrence et de fréquence par hôte
+	client      *http.Client                           // Client HTTP partagé (réutilise les connexions)
+	policy      CheckPolicy                            // Politique de vérification globale
+	tlsWarning  time.Duration                          // Préavis d'expiration des certificats TLS
+	changeBits  int                                    // Seuil de changement de contenu (bits de SimHash différents)
+	autoDisable time.Duration                          // Durée d'échec continu avant de désactiver un lien (0 = jamais)
+	knownStates map[uint]linkState                     // État connu de chaque lien: map[LinkID]état
+	mu          sync.Mutex                             // Mutex pour protéger l'accès concurrentiel à knownStates
+	running     atomic.Bool                            // Vrai pendant un cycle de vérification (évite les chevauchements)
+	cycles      sync.WaitGroup                         // Cycles en cours, attendus à l'arrêt
 }
 
 // NewUrlMonitor crée et retourne une nouvelle instance de UrlMonitor.
 // Retourne un pointeur
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+func NewUrlMonitor(linkRepo repository.LinkRepository, checkRepo repository.LinkCheckRepository,
+	windowRepo repository.MaintenanceWindowRepository, opts Options) *UrlMonitor {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	if opts.RequestTimeout <= 0 {
+		opts.RequestTimeout = 5 * time.Second
+	}
+	if opts.ContentChangeBits <= 0 {
+		opts.ContentChangeBits = defaultContentChangeBits
+	}
+	// Le contrôle des adresses se fait à la connexion, après résolution DNS : il couvre aussi
+	// les redirections et les noms dont la résolution a changé depuis la création du lien.
+	// Derrière un proxy, seule l'adresse du proxy serait contrôlée : il est ignoré tant que la
+	// politique contrôle les destinations.
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	dial := dialer.DialContext
+	proxy := http.ProxyFromEnvironment
+	if opts.URLPolicy != nil && opts.URLPolicy.Mode() != urlpolicy.ModeAllow {
+		dial = opts.URLPolicy.DialContext(dialer)
+		proxy = nil
+	}
 	return &UrlMonitor{
 		linkRepo:    linkRepo,
-		interval:    interval,
-		knownStates: make(map[uint]bool),
+		checkRepo:   checkRepo,
+		windowRepo:  windowRepo,
+		interval:    opts.Interval,
+		historyDays: opts.HistoryDays,
+		notifier:    opts.Notifier,
+		concurrency: opts.Concurrency,
+		limiter:     newHostLimiter(opts.PerHostConcurrency, opts.PerHostRate),
+		client: &http.Client{
+			Timeout:       opts.RequestTimeout,
+			CheckRedirect: checkRedirect,
+			Transport: &http.Transport{
+				Proxy:               proxy,
+				DialContext:         dial,
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		policy:      opts.Policy.withDefaults(),
+		tlsWarning:  opts.TLSExpiryWarning,
+		changeBits:  opts.ContentChangeBits,
+		autoDisable: opts.AutoDisableAfter,
+		knownStates: make(map[uint]linkState),
 	}
 }
 
+// linkState est l'état retenu pour un lien entre deux vérifications.
+type linkState struct {
+	up           bool      // Accessible, après application du seuil d'échecs
+	notifiedUp   bool      // Dernier état notifié (diffère de 'up' après une maintenance)
+	checkedAt    time.Time // Date de la dernière vérification
+	failures     int       // Échecs consécutifs
+	failingSince time.Time // Premier échec de la série en cours
+	downgraded   bool      // La chaîne de redirections passe de https à http
+	certWarned   time.Time // Expiration de certificat ayant déjà fait l'objet d'un avertissement
+	content      *snapshot // Dernière empreinte du contenu (nil = jamais calculée)
+}
+
+// snapshot est la dernière empreinte de contenu retenue pour un lien.
+type snapshot struct {
+	hash    string
+	simHash uint64
+	title   string
+}
+
 // Start lance la boucle de surveillance périodique des URLs.
-// Cette fonction est conçue pour être lancée dans une goroutine séparée.
-func (m *UrlMonitor) Start() {
+// Cette fonction est conçue pour être lancée dans une goroutine séparée ; elle se termine
+// dès que 'ctx' est annulé, y compris au milieu d'une vérification.
+func (m *UrlMonitor) Start(ctx context.Context) {
 	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
 	ticker := time.NewTicker(m.interval) // Crée un ticker qui envoie un signal à chaque intervalle
 	defer ticker.Stop()                  // S'assure que le ticker est arrêté quand Start se termine
 
+	// Restaure l'état connu depuis l'historique, pour détecter les changements survenus pendant l'arrêt
+	m.restoreStates()
+
 	// Exécute une première vérification immédiatement au démarrage
-	m.checkUrls()
+	m.startCycle(ctx)
 
 	// Boucle principale du moniteur, déclenchée par le ticker
-	for range ticker.C {
-		m.checkUrls()
+	for {
+		select {
+		case <-ctx.Done():
+			m.cycles.Wait()
+			log.Println("[MONITOR] Arrêt du moniteur d'URLs.")
+			return
+		case <-ticker.C:
+			m.startCycle(ctx)
+		}
+	}
+}
+
+// startCycle lance un cycle de vérification en arrière-plan, sauf si le précédent n'est pas terminé :
+// un cycle lent ne s'exécute jamais en même temps que le suivant.
+func (m *UrlMonitor) startCycle(ctx context.Context) {
+	if !m.running.CompareAndSwap(false, true) {
+		metrics.MonitorCyclesSkipped.Inc()
+		log.Println("[MONITOR] Cycle précédent toujours en cours, vérification ignorée pour cet intervalle.")
+		return
+	}
+	m.cycles.Add(1)
+	go func() {
+		defer m.cycles.Done()
+		defer m.running.Store(false)
+		m.checkUrls(ctx)
+	}()
+}
+
+// restoreStates initialise 'knownStates' à partir de la dernière vérification enregistrée de chaque lien.
+func (m *UrlMonitor) restoreStates() {
+	checks, err := m.checkRepo.GetLatestChecks()
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors de la restauration des états connus : %v", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, check := range checks {
+		state := linkState{up: check.IsUp(), notifiedUp: check.IsUp(), checkedAt: check.CheckedAt,
+			failures: check.ConsecutiveFailures, downgraded: check.HTTPSDowngrade}
+		if check.FailingSince != nil {
+			state.failingSince = *check.FailingSince
+		}
+		// Un certificat déjà dans la période de préavis a été signalé avant l'arrêt
+		if check.TLSNotAfter != nil && m.certExpiresSoon(*check.TLSNotAfter) {
+			state.certWarned = *check.TLSNotAfter
+		}
+		m.knownStates[check.LinkID] = state
+	}
+	log.Printf("[MONITOR] État restauré pour %d lien(s) depuis l'historique.", len(checks))
+
+	// La dernière empreinte peut précéder la dernière vérification (échec, mode désactivé entre-temps)
+	contentChecks, err := m.checkRepo.GetLatestContentChecks()
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors de la restauration des empreintes de contenu : %v", err)
+		return
+	}
+	for _, check := range contentChecks {
+		simHash, ok := parseSimHash(check.ContentSimHash)
+		if !ok {
+			continue
+		}
+		state := m.knownStates[check.LinkID]
+		state.content = &snapshot{hash: check.ContentHash, simHash: simHash, title: check.PageTitle}
+		m.knownStates[check.LinkID] = state
 	}
 }
 
-// checkUrls effectue une vérification de l'état de toutes les URLs longues enregistrées.
-func (m *UrlMonitor) checkUrls() {
+// IsDown indique si le moniteur a déclaré la destination du lien inaccessible lors de sa dernière
+// vérification. Un lien encore jamais vérifié n'est pas considéré comme inaccessible.
+func (m *UrlMonitor) IsDown(linkID uint) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, exists := m.knownStates[linkID]
+	return exists && !state.up
+}
+
+// urlJob est une requête de vérification, partagée par tous les liens qui pointent vers la même
+// URL avec une politique équivalente.
+type urlJob struct {
+	key    string
+	url    string
+	policy CheckPolicy
+}
+
+// urlResult est le résultat d'un urlJob.
+type urlResult struct {
+	key   string
+	check models.LinkCheck
+}
+
+// checkUrls effectue une vérification de l'état de toutes les URLs longues enregistrées et
+// historise le résultat de chaque lien ; la vérification est interrompue si 'ctx' est annulé.
+func (m *UrlMonitor) checkUrls(ctx context.Context) {
 	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
+	started := time.Now()
 
 	// Récupère tous les liens depuis le repository
 	// En cas d'erreur, log l'erreur et retourne
@@ -55,60 +248,575 @@ func (m *UrlMonitor) checkUrls() {
 		log.Printf("[MONITOR] ERREUR lors de la récupération des liens pour la surveillance : %v", err)
 		return
 	}
+	links = m.dueLinks(links, started)
 
+	requests := m.CheckLinks(ctx, links, func(link models.Link, check models.LinkCheck) {
+		m.recordCheck(link, check, m.policyFor(link).FailureThreshold)
+	})
+
+	if ctx.Err() != nil {
+		log.Println("[MONITOR] Vérification interrompue par l'arrêt du serveur.")
+		return
+	}
+	m.pruneHistory()
+	metrics.MonitorLastRun.Set(float64(time.Now().Unix()))
+	log.Printf("[MONITOR] Vérification de l'état des URLs terminée : %d requête(s) distincte(s) pour %d lien(s) en %v.",
+		requests, len(links), time.Since(started).Round(time.Millisecond))
+}
+
+// dueLinks retourne les liens à vérifier lors du cycle commencé à 'now'. Les liens dont la
+// surveillance est désactivée sont écartés et leur état oublié ; ceux qui ont un intervalle
+// propre ne sont vérifiés qu'une fois cet intervalle écoulé depuis leur dernière vérification,
+// à une demi-période de cycle près (les cycles ne sont pas parfaitement réguliers).
+func (m *UrlMonitor) dueLinks(links []models.Link, now time.Time) []models.Link {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	due := make([]models.Link, 0, len(links))
 	for _, link := range links {
-		// Vérifie l'accessibilité de chaque lien
-		currentState := m.isUrlAccessible(link.LongURL)
-
-		// Protéger l'accès à la map 'knownStates' car 'checkUrls' peut être exécuté concurremment
-		m.mu.Lock()
-		previousState, exists := m.knownStates[link.ID] // Récupère l'état précédent
-		m.knownStates[link.ID] = currentState           // Met à jour l'état actuel
-		m.mu.Unlock()
-
-		// Si c'est la première vérification pour ce lien, on initialise l'état sans notifier.
-		if !exists {
-			log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
-				link.ShortCode, link.LongURL, formatState(currentState))
+		if !link.Monitor.IsEnabled() {
+			delete(m.knownStates, link.ID)
 			continue
 		}
+		if minutes := link.Monitor.IntervalMinutes; minutes != nil && *minutes > 0 {
+			state, exists := m.knownStates[link.ID]
+			if exists && now.Add(m.interval/2).Sub(state.checkedAt) < time.Duration(*minutes)*time.Minute {
+				continue
+			}
+		}
+		due = append(due, link)
+	}
+	return due
+}
+
+// CheckLinks vérifie les destinations des liens sans rien enregistrer et appelle 'handle', dans
+// la goroutine appelante, pour chaque lien dont la vérification a abouti. Chaque URL distincte
+// n'est vérifiée qu'une fois par politique de vérification, par un pool de 'concurrency' workers
+// soumis aux limites par hôte. Retourne le nombre de requêtes effectuées ; les vérifications
+// restantes sont abandonnées si 'ctx' est annulé.
+func (m *UrlMonitor) CheckLinks(ctx context.Context, links []models.Link, handle func(models.Link, models.LinkCheck)) int {
+	// Déduplication : les liens pointant vers la même URL partagent une seule vérification
+	linksByKey := make(map[string][]models.Link)
+	var queue []urlJob
+	for _, link := range links {
+		policy := m.policyFor(link)
+		key := policy.requestKey(link.LongURL)
+		if _, seen := linksByKey[key]; !seen {
+			queue = append(queue, urlJob{key: key, url: link.LongURL, policy: policy})
+		}
+		linksByKey[key] = append(linksByKey[key], link)
+	}
 
-		// Compare l'état actuel avec l'état précédent.
-		// Si l'état a changé, générer une notification dans les logs.
-		if currentState != previousState {
-			log.Printf("[NOTIFICATION] Le lien %s (%s) est passé de %s à %s !",
-				link.ShortCode, link.LongURL, formatState(previousState), formatState(currentState))
+	jobs := make(chan urlJob)
+	results := make(chan urlResult)
+	var workers sync.WaitGroup
+	for i := 0; i < m.concurrency && i < len(queue); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				check, ok := m.checkUrl(ctx, job.url, job.policy)
+				if !ok {
+					// Requête annulée par l'arrêt : le résultat n'est pas significatif
+					continue
+				}
+				results <- urlResult{key: job.key, check: check}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, job := range queue {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
 
+	checked := 0
+	for res := range results {
+		checked++
+		observeCheck(res.check)
+		for _, link := range linksByKey[res.key] {
+			handle(link, res.check)
+		}
 	}
-	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
+	return checked
 }
 
-// isUrlAccessible effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL.
-func (m *UrlMonitor) isUrlAccessible(url string) bool {
-	// Définit un timeout de 5 secondes pour éviter de bloquer trop longtemps
-	client := http.Client{
-		Timeout: 5 * time.Second,
+// CheckNow vérifie immédiatement la destination d'un lien, en dehors des cycles, et enregistre
+// le résultat comme une vérification périodique (historique, état retenu, notifications).
+// Retourne la vérification enregistrée, ou l'erreur de 'ctx' si elle a été interrompue.
+func (m *UrlMonitor) CheckNow(ctx context.Context, link models.Link) (models.LinkCheck, error) {
+	policy := m.policyFor(link)
+	check, ok := m.checkUrl(ctx, link.LongURL, policy)
+	if !ok {
+		return check, ctx.Err()
 	}
+	observeCheck(check)
+	return m.recordCheck(link, check, policy.FailureThreshold), nil
+}
 
-	// Effectue une requête HEAD (plus légère que GET) sur l'URL.
-	// Un code de statut 2xx ou 3xx indique que l'URL est accessible.
-	resp, err := client.Head(url)
+// recordCheck historise le résultat d'une vérification pour un lien et notifie un éventuel changement d'état.
+// Un lien accessible, ou encore jamais vérifié, n'est déclaré inaccessible qu'après 'threshold' échecs consécutifs ;
+// une seule vérification réussie suffit à le déclarer de nouveau accessible.
+// Pendant une fenêtre de maintenance, l'état est historisé mais aucune notification n'est envoyée ;
+// la première vérification qui suit notifie l'écart éventuel avec le dernier état notifié.
+// Une destination refusée par la politique d'URL n'a pas été vérifiée : rien n'est historisé
+// et l'état retenu (échecs, désactivation automatique) reste inchangé.
+// Retourne la vérification telle qu'enregistrée.
+func (m *UrlMonitor) recordCheck(link models.Link, check models.LinkCheck, threshold int) models.LinkCheck {
+	check.LinkID = link.ID
+	if check.ErrorClass == models.CheckErrorBlocked {
+		log.Printf("[MONITOR] Destination du lien %s (%s) refusée par la politique d'URL, vérification ignorée.",
+			link.ShortCode, link.LongURL)
+		return check
+	}
+	window, err := m.windowRepo.GetActiveWindow(link.ID, check.CheckedAt)
 	if err != nil {
-		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
+		log.Printf("[MONITOR] ERREUR lors de la recherche des fenêtres de maintenance du lien %s : %v", link.ShortCode, err)
+	}
+	check.Maintenance = window != nil
+
+	// Protéger l'accès à la map 'knownStates' car les cycles et les vérifications peuvent être concurrents
+	m.mu.Lock()
+	previous, exists := m.knownStates[link.ID] // Récupère l'état précédent
+	// L'empreinte et la série d'échecs retenues pour l'ancienne destination ne s'appliquent pas
+	// à la nouvelle : le seuil et le délai de désactivation automatique repartent de zéro
+	if exists && link.LongURLChangedAt != nil && previous.checkedAt.Before(*link.LongURLChangedAt) {
+		previous.content = nil
+		previous.failures = 0
+		previous.failingSince = time.Time{}
+	}
+	current := linkState{up: check.Accessible, notifiedUp: previous.notifiedUp, checkedAt: check.CheckedAt,
+		downgraded: previous.downgraded, certWarned: previous.certWarned, content: previous.content}
+	if !check.Accessible {
+		current.failures = previous.failures + 1
+		current.failingSince = previous.failingSince
+		if previous.failures == 0 || current.failingSince.IsZero() {
+			current.failingSince = check.CheckedAt
+		}
+		// Un lien accessible, ou encore jamais vérifié, le reste tant que le seuil n'est pas atteint
+		if (!exists || previous.up) && current.failures < threshold {
+			current.up = true
+		}
+	}
+	// Le changement d'état est notifié par rapport au dernier état notifié, sauf en maintenance
+	announce := false
+	if !exists {
+		current.notifiedUp = current.up
+	} else if !check.Maintenance {
+		announce = current.up != previous.notifiedUp
+		current.notifiedUp = current.up
+	}
+	// Les avertissements ne sont émis qu'une fois : au premier passage de https à http,
+	// et une fois par date d'expiration de certificat (un renouvellement en change la date).
+	// En maintenance, ils sont reportés à la première vérification qui suit.
+	warnDowngrade, warnCert := false, false
+	if check.StatusCode != 0 && !check.Maintenance {
+		warnDowngrade = check.HTTPSDowngrade && !previous.downgraded
+		current.downgraded = check.HTTPSDowngrade
+	}
+	if check.TLSNotAfter != nil && m.certExpiresSoon(*check.TLSNotAfter) && !previous.certWarned.Equal(*check.TLSNotAfter) &&
+		!check.Maintenance {
+		warnCert = true
+		current.certWarned = *check.TLSNotAfter
+	}
+	// Le contenu est comparé à la dernière empreinte retenue, quel que soit l'écart de temps
+	var changedFrom *snapshot
+	if check.ContentHash != "" {
+		simHash, _ := parseSimHash(check.ContentSimHash)
+		if previous.content != nil && m.contentChanged(*previous.content, check.ContentHash, simHash, check.PageTitle) {
+			check.ContentChanged = true
+			if !check.Maintenance {
+				changedFrom = previous.content
+			}
+		}
+		current.content = &snapshot{hash: check.ContentHash, simHash: simHash, title: check.PageTitle}
+	}
+	m.knownStates[link.ID] = current // Met à jour l'état actuel
+	m.mu.Unlock()
+
+	// Historise la vérification avec l'état retenu
+	check.ConsecutiveFailures = current.failures
+	if !current.failingSince.IsZero() {
+		check.FailingSince = &current.failingSince
+	}
+	check.State = models.CheckStateDown
+	if current.up {
+		check.State = models.CheckStateUp
+	}
+	if err := m.checkRepo.CreateCheck(&check); err != nil {
+		log.Printf("[MONITOR] ERREUR lors de l'enregistrement de la vérification du lien %s : %v", link.ShortCode, err)
+	}
+
+	if warnDowngrade {
+		m.warnHTTPSDowngrade(link, check)
+	}
+	if warnCert {
+		m.warnCertExpiry(link, check)
+	}
+	if changedFrom != nil {
+		m.notifyContentChange(link, check, *changedFrom)
+	}
+
+	// Un lien mort depuis trop longtemps est désactivé (jamais pendant une maintenance),
+	// et réactivé dès que sa destination est de nouveau accessible. Après une réactivation
+	// manuelle, la durée d'échec n'est comptée qu'à partir de celle-ci.
+	failingSince := current.failingSince
+	if link.ReenabledAt != nil && link.ReenabledAt.After(failingSince) {
+		failingSince = *link.ReenabledAt
+	}
+	switch {
+	case m.autoDisable > 0 && !current.up && !link.Disabled && !check.Maintenance &&
+		check.CheckedAt.Sub(failingSince) >= m.autoDisable:
+		m.disableDeadLink(link, check)
+	case current.up && link.Disabled && link.AutoDisabled:
+		m.reenableLink(link, check)
+	}
+
+	// Si c'est la première vérification pour ce lien, on initialise l'état sans notifier.
+	if !exists {
+		log.Printf("[MONITOR] État initial pour le lien %s (%s) : %s",
+			link.ShortCode, link.LongURL, formatState(current.up))
+		return check
+	}
+	if current.up && !check.Accessible {
+		log.Printf("[MONITOR] Échec %d/%d pour le lien %s (%s), état inchangé.",
+			current.failures, threshold, link.ShortCode, link.LongURL)
+	}
+	if current.up != previous.up {
+		metrics.MonitorStateChanges.Inc(checkResult(current.up))
+		if check.Maintenance {
+			log.Printf("[MONITOR] Le lien %s (%s) est passé de %s à %s pendant une maintenance, notification suspendue.",
+				link.ShortCode, link.LongURL, formatState(previous.up), formatState(current.up))
+		}
+	}
+
+	// Si l'état a changé depuis la dernière notification, notifier les destinataires configurés.
+	if announce {
+		message := fmt.Sprintf("Le lien %s (%s) est passé de %s à %s !",
+			link.ShortCode, link.LongURL, formatState(previous.notifiedUp), formatState(current.up))
+		if !current.up && current.failures > 1 {
+			message = fmt.Sprintf("Le lien %s (%s) est passé de %s à %s après %d échecs consécutifs !",
+				link.ShortCode, link.LongURL, formatState(previous.notifiedUp), formatState(current.up), current.failures)
+		}
+		m.notifier.Dispatch(notify.Event{
+			Type:                notify.EventStateChange,
+			LinkID:              link.ID,
+			ShortCode:           link.ShortCode,
+			LongURL:             link.LongURL,
+			PreviousState:       formatState(previous.notifiedUp),
+			CurrentState:        formatState(current.up),
+			StatusCode:          check.StatusCode,
+			ErrorClass:          check.ErrorClass,
+			ConsecutiveFailures: current.failures,
+			Message:             message,
+			OccurredAt:          check.CheckedAt,
+		})
+	}
+	return check
+}
+
+// certExpiresSoon indique si un certificat expirant à 'notAfter' est dans la période de préavis.
+func (m *UrlMonitor) certExpiresSoon(notAfter time.Time) bool {
+	return m.tlsWarning > 0 && time.Until(notAfter) <= m.tlsWarning
+}
+
+// warnCertExpiry signale que le certificat TLS de la destination d'un lien expire bientôt.
+func (m *UrlMonitor) warnCertExpiry(link models.Link, check models.LinkCheck) {
+	days := int(math.Ceil(time.Until(*check.TLSNotAfter).Hours() / 24))
+	m.notifier.Dispatch(notify.Event{
+		Type:          notify.EventCertExpiring,
+		LinkID:        link.ID,
+		ShortCode:     link.ShortCode,
+		LongURL:       link.LongURL,
+		FinalURL:      check.FinalURL,
+		CertExpiresAt: check.TLSNotAfter,
+		Message: fmt.Sprintf("Le certificat TLS de %s (lien %s) expire le %s, dans %d jour(s) !",
+			check.FinalURL, link.ShortCode, check.TLSNotAfter.Format("2006-01-02"), days),
+		OccurredAt: check.CheckedAt,
+	})
+}
+
+// contentChanged indique si une page diffère significativement de sa dernière empreinte :
+// titre modifié, ou texte dont la SimHash s'écarte d'au moins 'changeBits' bits.
+// Des retouches mineures (date, compteur) changent le hash sans dépasser le seuil.
+func (m *UrlMonitor) contentChanged(previous snapshot, hash string, simHash uint64, title string) bool {
+	if hash == previous.hash {
 		return false
 	}
-	
-	defer resp.Body.Close()
-	
+	return title != previous.title || contentDistance(simHash, previous.simHash) >= m.changeBits
+}
+
+// notifyContentChange signale que le contenu de la destination d'un lien a changé.
+func (m *UrlMonitor) notifyContentChange(link models.Link, check models.LinkCheck, previous snapshot) {
+	metrics.MonitorContentChanges.Inc()
+	message := fmt.Sprintf("Le contenu de la destination du lien %s (%s) a changé !", link.ShortCode, link.LongURL)
+	if check.PageTitle != previous.title {
+		message = fmt.Sprintf("Le contenu de la destination du lien %s (%s) a changé : titre %q devenu %q !",
+			link.ShortCode, link.LongURL, previous.title, check.PageTitle)
+	}
+	m.notifier.Dispatch(notify.Event{
+		Type:          notify.EventContentChanged,
+		LinkID:        link.ID,
+		ShortCode:     link.ShortCode,
+		LongURL:       link.LongURL,
+		FinalURL:      check.FinalURL,
+		PreviousTitle: previous.title,
+		PageTitle:     check.PageTitle,
+		Message:       message,
+		OccurredAt:    check.CheckedAt,
+	})
+}
+
+// disableDeadLink désactive un lien dont la destination est inaccessible depuis plus de
+// 'autoDisable', en enregistrant le motif, et notifie la désactivation.
+func (m *UrlMonitor) disableDeadLink(link models.Link, check models.LinkCheck) {
+	failure := check.ErrorClass
+	if check.StatusCode != 0 {
+		failure = fmt.Sprintf("statut %d", check.StatusCode)
+	}
+	reason := fmt.Sprintf("destination inaccessible depuis le %s (%s, %d échecs consécutifs)",
+		check.FailingSince.Format("2006-01-02 15:04 MST"), failure, check.ConsecutiveFailures)
+	changed, err := m.linkRepo.SetAutoDisabled(link.ID, true, reason, check.CheckedAt)
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors de la désactivation automatique du lien %s : %v", link.ShortCode, err)
+		return
+	}
+	if !changed {
+		return // Désactivé manuellement entre-temps
+	}
+	metrics.MonitorAutoDisables.Inc("disabled")
+	m.notifier.Dispatch(notify.Event{
+		Type:                notify.EventLinkDisabled,
+		LinkID:              link.ID,
+		ShortCode:           link.ShortCode,
+		LongURL:             link.LongURL,
+		StatusCode:          check.StatusCode,
+		ErrorClass:          check.ErrorClass,
+		ConsecutiveFailures: check.ConsecutiveFailures,
+		FailingSince:        check.FailingSince,
+		Message: fmt.Sprintf("Le lien %s (%s) a été désactivé automatiquement : %s.",
+			link.ShortCode, link.LongURL, reason),
+		OccurredAt: check.CheckedAt,
+	})
+}
+
+// reenableLink réactive un lien désactivé automatiquement dont la destination est de nouveau accessible.
+func (m *UrlMonitor) reenableLink(link models.Link, check models.LinkCheck) {
+	changed, err := m.linkRepo.SetAutoDisabled(link.ID, false, "", check.CheckedAt)
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors de la réactivation automatique du lien %s : %v", link.ShortCode, err)
+		return
+	}
+	if !changed {
+		return // Désactivation rendue manuelle ou lien réactivé entre-temps
+	}
+	metrics.MonitorAutoDisables.Inc("reenabled")
+	m.notifier.Dispatch(notify.Event{
+		Type:       notify.EventLinkReenabled,
+		LinkID:     link.ID,
+		ShortCode:  link.ShortCode,
+		LongURL:    link.LongURL,
+		StatusCode: check.StatusCode,
+		Message: fmt.Sprintf("Le lien %s (%s) a été réactivé automatiquement : sa destination est de nouveau accessible.",
+			link.ShortCode, link.LongURL),
+		OccurredAt: check.CheckedAt,
+	})
+}
+
+// warnHTTPSDowngrade signale que la destination d'un lien redirige de https vers http.
+func (m *UrlMonitor) warnHTTPSDowngrade(link models.Link, check models.LinkCheck) {
+	m.notifier.Dispatch(notify.Event{
+		Type:          notify.EventHTTPSDowngrade,
+		LinkID:        link.ID,
+		ShortCode:     link.ShortCode,
+		LongURL:       link.LongURL,
+		FinalURL:      check.FinalURL,
+		RedirectChain: check.RedirectChain,
+		Message: fmt.Sprintf("La destination du lien %s (%s) redirige de HTTPS vers HTTP (%s) !",
+			link.ShortCode, link.LongURL, check.FinalURL),
+		OccurredAt: check.CheckedAt,
+	})
+}
+
+// pruneHistory supprime les vérifications plus anciennes que 'historyDays'.
+func (m *UrlMonitor) pruneHistory() {
+	if m.historyDays <= 0 {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -m.historyDays)
+	deleted, err := m.checkRepo.DeleteChecksBefore(cutoff)
+	if err != nil {
+		log.Printf("[MONITOR] ERREUR lors de la purge de l'historique des vérifications : %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("[MONITOR] %d vérification(s) de plus de %d jour(s) supprimée(s).", deleted, m.historyDays)
+	}
+}
+
+// checkUrl effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL et retourne
+// le résultat détaillé (statut, latence, classe d'erreur, chaîne de redirections, URL finale,
+// expiration du certificat TLS).
+// Si HEAD échoue ou renvoie un statut refusé (beaucoup de sites répondent 403 ou 405 à HEAD)
+// et que la politique le permet, la vérification est refaite en GET.
+// Lorsque la politique suit l'empreinte du contenu, la vérification est faite directement en GET
+// et l'empreinte du corps (borné à ContentMaxBytes) est ajoutée au résultat des pages accessibles.
+// Le second résultat est false si la vérification a été interrompue par l'annulation de 'ctx'.
+// La vérification attend d'abord l'autorisation du limiteur de l'hôte de destination, et le repli
+// en GET le délai minimal entre deux requêtes vers cet hôte.
+func (m *UrlMonitor) checkUrl(ctx context.Context, rawURL string, policy CheckPolicy) (models.LinkCheck, bool) {
+	check := models.LinkCheck{FinalURL: rawURL, Method: http.MethodHead}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		log.Printf("[MONITOR] URL invalide '%s': %v", rawURL, err)
+		check.CheckedAt = time.Now().UTC()
+		check.ErrorClass = models.CheckErrorInvalidURL
+		return check, true
+	}
+	release, err := m.limiter.acquire(ctx, parsed.Host)
+	if err != nil {
+		return check, false
+	}
+	defer release()
+
+	start := time.Now()
+	check.CheckedAt = start.UTC()
+
+	// Effectue d'abord une requête HEAD (plus légère que GET) sur l'URL, sauf si le contenu est nécessaire.
+	var res probeResult
+	if policy.ContentFingerprint {
+		check.Method = http.MethodGet
+		res, err = m.probe(ctx, http.MethodGet, rawURL, policy)
+	} else {
+		res, err = m.probe(ctx, http.MethodHead, rawURL, policy)
+		if policy.GetFallback && ctx.Err() == nil && shouldFallback(res.status, err, policy) {
+			// Le repli est une seconde requête vers le même hôte : elle respecte aussi sa fréquence
+			// maximale, sans que l'attente ne compte dans la latence
+			waitStart := time.Now()
+			if err := m.limiter.wait(ctx, parsed.Host); err != nil {
+				return check, false
+			}
+			start = start.Add(time.Since(waitStart))
+			check.Method = http.MethodGet
+			res, err = m.probe(ctx, http.MethodGet, rawURL, policy)
+		}
+	}
+	check.LatencyMs = time.Since(start).Milliseconds()
+	check.RedirectChain = res.hops
+	check.HTTPSDowngrade = isHTTPSDowngrade(res.hops, res.finalURL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return check, false
+		}
+		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s' (%s): %v", rawURL, check.Method, err)
+		check.ErrorClass = classifyError(err)
+		return check, true
+	}
+
 	// Déterminer l'accessibilité basée sur le code de statut HTTP.
-	return resp.StatusCode >= 200 && resp.StatusCode < 400 // Codes 2xx ou 3xx
+	check.StatusCode = res.status
+	check.FinalURL = res.finalURL
+	check.TLSNotAfter = res.tlsNotAfter
+	check.Accessible = policy.AcceptedStatusCodes.Contains(res.status)
+	if !check.Accessible {
+		check.ErrorClass = models.CheckErrorHTTPStatus
+	} else if policy.ContentFingerprint {
+		// Les pages d'erreur ne remplacent pas l'empreinte de la page attendue
+		fp := fingerprintContent(res.body)
+		check.ContentHash = fp.hash
+		check.ContentSimHash = formatSimHash(fp.simHash)
+		check.PageTitle = fp.title
+	}
+	return check, true
+}
+
+// probeResult est le résultat d'une requête de vérification.
+type probeResult struct {
+	status      int                  // Statut de la réponse finale
+	finalURL    string               // URL de la réponse finale
+	hops        []models.RedirectHop // Redirections suivies, y compris en cas d'erreur
+	tlsNotAfter *time.Time           // Expiration du certificat de la réponse finale (https uniquement)
+	body        []byte               // Début du corps, conservé pour l'empreinte du contenu
+}
+
+// probe envoie une requête 'method' vers l'URL en suivant les redirections et retourne la
+// réponse finale, la chaîne de redirections et l'expiration du certificat présenté.
+// En GET, seuls les premiers octets du corps sont lus pour confirmer que le contenu est servi ;
+// ils sont conservés (jusqu'à ContentMaxBytes) si la politique suit l'empreinte du contenu.
+func (m *UrlMonitor) probe(ctx context.Context, method, rawURL string, policy CheckPolicy) (probeResult, error) {
+	ctx, trace := withRedirectTrace(ctx)
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return probeResult{}, err
+	}
+	req.Header.Set("User-Agent", policy.UserAgent)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return probeResult{hops: trace.hops}, err
+	}
+	defer resp.Body.Close()
+
+	res := probeResult{status: resp.StatusCode, finalURL: resp.Request.URL.String(), hops: trace.hops}
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		notAfter := resp.TLS.PeerCertificates[0].NotAfter.UTC()
+		res.tlsNotAfter = &notAfter
+	}
+	switch {
+	case method != http.MethodGet:
+	case policy.ContentFingerprint:
+		if res.body, err = io.ReadAll(io.LimitReader(resp.Body, policy.ContentMaxBytes)); err != nil {
+			return probeResult{hops: trace.hops}, err
+		}
+	default:
+		if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, policy.GetFallbackMaxBytes)); err != nil {
+			return probeResult{hops: trace.hops}, err
+		}
+	}
+	return res, nil
+}
+
+// shouldFallback indique si le résultat d'une requête HEAD justifie un nouvel essai en GET :
+// statut refusé, ou erreur susceptible d'être propre à HEAD (connexion coupée, réponse invalide).
+// Les erreurs DNS, TLS, les délais dépassés et les URLs invalides échoueraient de la même façon.
+func shouldFallback(status int, err error, policy CheckPolicy) bool {
+	if err == nil {
+		return !policy.AcceptedStatusCodes.Contains(status)
+	}
+	switch classifyError(err) {
+	case models.CheckErrorConnection, models.CheckErrorOther:
+		return true
+	}
+	return false
+}
+
+// observeCheck met à jour les métriques du moniteur pour une requête de vérification.
+func observeCheck(check models.LinkCheck) {
+	metrics.MonitorCheckDuration.Observe(float64(check.LatencyMs) / 1000)
+	metrics.MonitorChecks.Inc(checkResult(check.Accessible))
+}
+
+// checkResult retourne le label de métrique correspondant à un état.
+func checkResult(accessible bool) string {
+	if accessible {
+		return "up"
+	}
+	return "down"
 }
 
-// formatState est une fonction utilitaire pour rendre l'état plus lisible dans les logs.
+// formatState est une fonction utilitaire pour rendre l'état plus lisible dans les logs et les notifications.
 func formatState(accessible bool) string {
 	if accessible {
-		return "ACCESSIBLE"
+		return notify.StateAccessible
 	}
-	return "INACCESSIBLE"
+	return notify.StateInaccessible
 }
@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// fakeCheckRepository conserve en mémoire les vérifications historisées.
+type fakeCheckRepository struct {
+	mu     sync.Mutex
+	checks []models.LinkCheck
+}
+
+func (r *fakeCheckRepository) CreateCheck(check *models.LinkCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, *check)
+	return nil
+}
+
+func (r *fakeCheckRepository) GetLatestChecks() ([]models.LinkCheck, error)        { return nil, nil }
+func (r *fakeCheckRepository) GetLatestContentChecks() ([]models.LinkCheck, error) { return nil, nil }
+func (r *fakeCheckRepository) ListRecentChecks(uint, int) ([]models.LinkCheck, error) {
+	return nil, nil
+}
+func (r *fakeCheckRepository) CountChecksSince(uint, time.Time) (repository.CheckCounts, error) {
+	return repository.CheckCounts{}, nil
+}
+func (r *fakeCheckRepository) DeleteChecksBefore(time.Time) (int64, error) { return 0, nil }
+
+// noWindows est un MaintenanceWindowRepository sans aucune fenêtre de maintenance.
+type noWindows struct{}
+
+func (noWindows) CreateWindow(*models.MaintenanceWindow) error              { return nil }
+func (noWindows) ListWindows(time.Time) ([]models.MaintenanceWindow, error) { return nil, nil }
+func (noWindows) GetActiveWindow(uint, time.Time) (*models.MaintenanceWindow, error) {
+	return nil, nil
+}
+func (noWindows) DeleteWindow(uint) error { return nil }
+
+func newTestMonitor(t *testing.T) (*UrlMonitor, *fakeCheckRepository) {
+	t.Helper()
+	checks := &fakeCheckRepository{}
+	return NewUrlMonitor(nil, checks, noWindows{}, Options{}), checks
+}
+
+func failedCheck(at time.Time) models.LinkCheck {
+	return models.LinkCheck{CheckedAt: at, ErrorClass: models.CheckErrorConnection}
+}
+
+func TestFailureThresholdAppliesToFirstChecks(t *testing.T) {
+	m, checks := newTestMonitor(t)
+	link := models.Link{ID: 1, ShortCode: "abc123", LongURL: "https://example.com/"}
+	start := time.Now()
+
+	// Lien jamais vérifié : les premiers échecs ne suffisent pas à le déclarer inaccessible
+	for i, wantState := range []string{models.CheckStateUp, models.CheckStateUp, models.CheckStateDown} {
+		check := m.recordCheck(link, failedCheck(start.Add(time.Duration(i)*time.Minute)), 3)
+		if check.State != wantState {
+			t.Fatalf("check %d: state = %q, want %q", i+1, check.State, wantState)
+		}
+		if check.ConsecutiveFailures != i+1 {
+			t.Fatalf("check %d: consecutive failures = %d, want %d", i+1, check.ConsecutiveFailures, i+1)
+		}
+		if down := m.IsDown(link.ID); down != (wantState == models.CheckStateDown) {
+			t.Fatalf("check %d: IsDown = %v", i+1, down)
+		}
+	}
+	if len(checks.checks) != 3 {
+		t.Fatalf("recorded %d check(s), want 3", len(checks.checks))
+	}
+
+	// Une seule vérification réussie rétablit le lien
+	check := m.recordCheck(link, models.LinkCheck{CheckedAt: start.Add(3 * time.Minute), Accessible: true}, 3)
+	if check.State != models.CheckStateUp || m.IsDown(link.ID) {
+		t.Fatalf("state after success = %q, want up", check.State)
+	}
+}
+
+func TestFailureThresholdOfOneFlipsOnFirstFailure(t *testing.T) {
+	m, _ := newTestMonitor(t)
+	link := models.Link{ID: 1, ShortCode: "abc123", LongURL: "https://example.com/"}
+	if check := m.recordCheck(link, failedCheck(time.Now()), 1); check.State != models.CheckStateDown {
+		t.Fatalf("state = %q, want down", check.State)
+	}
+}
+
+func pageCheck(at time.Time, hash, title string) models.LinkCheck {
+	return models.LinkCheck{CheckedAt: at, Accessible: true, StatusCode: 200,
+		ContentHash: hash, ContentSimHash: formatSimHash(0), PageTitle: title}
+}
+
+func TestLongURLChangeForgetsContentSnapshot(t *testing.T) {
+	m, _ := newTestMonitor(t)
+	link := models.Link{ID: 1, ShortCode: "abc123", LongURL: "https://example.com/old"}
+	start := time.Now()
+	m.recordCheck(link, pageCheck(start, "old", "Ancienne page"), 1)
+
+	// Même destination : le changement de titre est signalé
+	if check := m.recordCheck(link, pageCheck(start.Add(time.Minute), "old2", "Page renommée"), 1); !check.ContentChanged {
+		t.Fatal("title change on the same URL not reported")
+	}
+
+	// Nouvelle destination : la première empreinte n'est comparée à rien
+	changedAt := start.Add(2 * time.Minute)
+	link.LongURL, link.LongURLChangedAt = "https://example.com/new", &changedAt
+	if check := m.recordCheck(link, pageCheck(start.Add(3*time.Minute), "new", "Nouvelle page"), 1); check.ContentChanged {
+		t.Fatal("first check of the new URL reported as a content change")
+	}
+	if check := m.recordCheck(link, pageCheck(start.Add(4*time.Minute), "new2", "Nouvelle page v2"), 1); !check.ContentChanged {
+		t.Fatal("title change after the URL change not reported")
+	}
+}
+
+func TestLongURLChangeResetsFailureRun(t *testing.T) {
+	m, _ := newTestMonitor(t)
+	link := models.Link{ID: 1, ShortCode: "abc123", LongURL: "https://example.com/old"}
+	start := time.Now().Add(-30 * 24 * time.Hour)
+	m.recordCheck(link, failedCheck(start), 1)
+	m.recordCheck(link, failedCheck(start.Add(time.Hour)), 1)
+
+	changedAt := time.Now()
+	link.LongURL, link.LongURLChangedAt = "https://example.com/new", &changedAt
+	at := changedAt.Add(time.Minute)
+	check := m.recordCheck(link, failedCheck(at), 3)
+	if check.ConsecutiveFailures != 1 {
+		t.Fatalf("consecutive failures = %d, want 1 after the URL change", check.ConsecutiveFailures)
+	}
+	if check.FailingSince == nil || !check.FailingSince.Equal(at) {
+		t.Fatalf("failing since = %v, want %v", check.FailingSince, at)
+	}
+}
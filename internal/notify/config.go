@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// Types de notifiers acceptés dans monitor.notifiers.
+const (
+	TypeLog     = "log"
+	TypeWebhook = "webhook"
+)
+
+const defaultWebhookRetries = 3
+
+// NewDispatcherFromConfig construit le Dispatcher décrit par monitor.notifiers.
+// Sans notifier configuré, les événements sont écrits dans les logs, comme auparavant.
+func NewDispatcherFromConfig(cfgs []config.NotifierConfig) (*Dispatcher, error) {
+	if len(cfgs) == 0 {
+		return NewDispatcher(Route{Notifier: LogNotifier{}}), nil
+	}
+
+	routes := make([]Route, 0, len(cfgs))
+	for i, c := range cfgs {
+		var (
+			n   Notifier
+			err error
+		)
+		switch strings.ToLower(c.Type) {
+		case TypeLog:
+			n = LogNotifier{}
+		case TypeWebhook:
+			retries := defaultWebhookRetries
+			if c.MaxRetries != nil {
+				retries = *c.MaxRetries
+			}
+			n, err = NewWebhookNotifier(WebhookOptions{
+				URL:            c.URL,
+				Secret:         c.Secret,
+				MaxRetries:     retries,
+				InitialBackoff: time.Second,
+				Timeout:        time.Duration(c.TimeoutSeconds) * time.Second,
+			})
+		default:
+			err = fmt.Errorf("unknown type %q (expected %s or %s)", c.Type, TypeLog, TypeWebhook)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("monitor.notifiers[%d]: %w", i, err)
+		}
+
+		route := Route{Notifier: n}
+		if len(c.Links) > 0 {
+			route.ShortCodes = make(map[string]struct{}, len(c.Links))
+			for _, code := range c.Links {
+				route.ShortCodes[strings.TrimSpace(code)] = struct{}{} // Les codes courts sont sensibles à la casse
+			}
+		}
+		routes = append(routes, route)
+	}
+	return NewDispatcher(routes...), nil
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Types d'événements émis par le moniteur.
+const (
+	EventStateChange    = "state_change"    // Passage d'ACCESSIBLE à INACCESSIBLE ou inversement
+	EventCertExpiring   = "cert_expiring"   // Certificat TLS de la destination proche de l'expiration
+	EventHTTPSDowngrade = "https_downgrade" // Redirection de https vers http dans la chaîne de la destination
+	EventContentChanged = "content_changed" // Contenu de la destination significativement différent de la dernière empreinte
+	EventLinkDisabled   = "link_disabled"   // Lien désactivé automatiquement, destination inaccessible trop longtemps
+	EventLinkReenabled  = "link_reenabled"  // Lien désactivé automatiquement puis réactivé au rétablissement de la destination
+)
+
+// États d'un lien dans les événements.
+const (
+	StateAccessible   = "ACCESSIBLE"
+	StateInaccessible = "INACCESSIBLE"
+)
+
+// Event décrit un changement ou un avertissement observé par le moniteur sur un lien.
+// Il est sérialisé tel quel dans le corps des webhooks.
+type Event struct {
+	Type                string               `json:"type"`
+	LinkID              uint                 `json:"link_id"`
+	ShortCode           string               `json:"short_code"`
+	LongURL             string               `json:"long_url"`
+	PreviousState       string               `json:"previous_state,omitempty"`
+	CurrentState        string               `json:"current_state,omitempty"`
+	StatusCode          int                  `json:"status_code,omitempty"`
+	ErrorClass          string               `json:"error_class,omitempty"`
+	ConsecutiveFailures int                  `json:"consecutive_failures,omitempty"` // Échecs ayant conduit à l'état INACCESSIBLE
+	FailingSince        *time.Time           `json:"failing_since,omitempty"`        // Premier échec de la série en cours
+	FinalURL            string               `json:"final_url,omitempty"`            // URL atteinte après les redirections
+	RedirectChain       []models.RedirectHop `json:"redirect_chain,omitempty"`
+	CertExpiresAt       *time.Time           `json:"cert_expires_at,omitempty"`
+	PreviousTitle       string               `json:"previous_title,omitempty"` // Titre de la page lors de la dernière empreinte
+	PageTitle           string               `json:"page_title,omitempty"`
+	Message             string               `json:"message"`
+	OccurredAt          time.Time            `json:"occurred_at"`
+}
+
+// Notifier est implémenté par chaque canal de notification (logs, webhook, ...).
+type Notifier interface {
+	// Name identifie le canal dans les logs.
+	Name() string
+	// Notify délivre l'événement ; les retries éventuels sont à la charge de l'implémentation.
+	Notify(ctx context.Context, event Event) error
+}
+
+// Route associe un Notifier aux liens dont il doit recevoir les événements.
+type Route struct {
+	Notifier   Notifier
+	ShortCodes map[string]struct{} // Vide = tous les liens
+}
+
+// matches indique si l'événement concerne un lien suivi par la route.
+func (r Route) matches(event Event) bool {
+	if len(r.ShortCodes) == 0 {
+		return true
+	}
+	_, ok := r.ShortCodes[event.ShortCode]
+	return ok
+}
+
+// Dispatcher distribue les événements aux notifiers concernés, chacun dans sa propre goroutine
+// pour qu'un webhook lent ne retarde pas le moniteur.
+type Dispatcher struct {
+	routes []Route
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher crée un Dispatcher à partir de ses routes.
+func NewDispatcher(routes ...Route) *Dispatcher {
+	return &Dispatcher{routes: routes}
+}
+
+// Dispatch envoie l'événement à tous les notifiers dont le filtre correspond. L'appel ne bloque pas.
+func (d *Dispatcher) Dispatch(event Event) {
+	if d == nil {
+		return
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	for _, route := range d.routes {
+		if !route.matches(event) {
+			continue
+		}
+		d.wg.Add(1)
+		go func(n Notifier) {
+			defer d.wg.Done()
+			if err := n.Notify(context.Background(), event); err != nil {
+				log.Printf("[NOTIFY] Échec de la notification %s pour le lien %s : %v", n.Name(), event.ShortCode, err)
+			}
+		}(route.Notifier)
+	}
+}
+
+// Wait attend la fin des notifications en cours, au plus jusqu'à l'annulation de 'ctx'.
+func (d *Dispatcher) Wait(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Println("[NOTIFY] Arrêt avant la fin de certaines notifications.")
+	}
+}
+
+// LogNotifier écrit les événements dans les logs du serveur.
+type LogNotifier struct{}
+
+// Name implémente Notifier.
+func (LogNotifier) Name() string { return "log" }
+
+// Notify implémente Notifier.
+func (LogNotifier) Notify(_ context.Context, event Event) error {
+	log.Printf("[NOTIFICATION] %s", event.Message)
+	return nil
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// En-têtes ajoutés aux requêtes des webhooks.
+const (
+	SignatureHeader = "X-Urlshortener-Signature" // "sha256=" + HMAC-SHA256 hexadécimal du corps
+	EventHeader     = "X-Urlshortener-Event"     // Type de l'événement
+)
+
+// WebhookOptions configure un WebhookNotifier.
+type WebhookOptions struct {
+	URL            string
+	Secret         string        // Clé HMAC ; vide = pas d'en-tête de signature
+	MaxRetries     int           // Nombre de nouvelles tentatives après un échec
+	InitialBackoff time.Duration // Attente avant la première nouvelle tentative, doublée ensuite
+	Timeout        time.Duration // Délai maximal de chaque tentative
+}
+
+// WebhookNotifier envoie les événements en JSON (POST) à une URL, signés par HMAC-SHA256.
+// Les erreurs réseau et les réponses 429/5xx sont retentées avec un backoff exponentiel ;
+// les autres réponses 4xx sont considérées comme définitives.
+type WebhookNotifier struct {
+	opts   WebhookOptions
+	client *http.Client
+}
+
+// NewWebhookNotifier crée un WebhookNotifier.
+func NewWebhookNotifier(opts WebhookOptions) (*WebhookNotifier, error) {
+	if opts.URL == "" {
+		return nil, errors.New("webhook url is required")
+	}
+	if opts.MaxRetries < 0 {
+		return nil, fmt.Errorf("invalid webhook max retries %d", opts.MaxRetries)
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	return &WebhookNotifier{opts: opts, client: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+// Name implémente Notifier.
+func (w *WebhookNotifier) Name() string { return "webhook " + w.opts.URL }
+
+// Notify implémente Notifier.
+func (w *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	backoff := w.opts.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := w.send(ctx, event.Type, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= w.opts.MaxRetries {
+			return fmt.Errorf("after %d attempt(s): %w", attempt+1, err)
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// send effectue une tentative de livraison et indique si un échec mérite d'être retenté.
+func (w *WebhookNotifier) send(ctx context.Context, eventType string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, eventType)
+	if w.opts.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.opts.Secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook responded %d", resp.StatusCode)
+	}
+}
+
+// Sign calcule la valeur de l'en-tête de signature pour 'body' : "sha256=" suivi du
+// HMAC-SHA256 hexadécimal. Le destinataire la recalcule avec le secret partagé pour
+// authentifier la requête.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
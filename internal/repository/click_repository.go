@@ -2,6 +2,7 @@ package repository
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
@@ -11,8 +12,56 @@ import (
 // pour les opérations sur les clics. Cette abstraction permet à la couche service
 // de rester indépendante de l'implémentation spécifique de la base de données.
 type ClickRepository interface {
-	CreateClick(click *models.Click) error           // Crée un nouveau click dans la base de données
-	CountClicksByLinkID(linkID uint) (int, error)    // Compte le nombre de clicks pour un lien donné
+	CreateClick(click *models.Click) error                                                                           // Crée un nouveau click dans la base de données
+	CreateClicks(clicks []models.Click) error                                                                        // Crée plusieurs clicks dans une seule transaction
+	CountClicksByLinkID(linkID uint) (int, error)                                                                    // Compte le nombre de clicks pour un lien donné
+	CountClicksBySlot(linkID uint, from, to time.Time, slot time.Duration, includeBots bool) ([]SlotCount, error)    // Agrège les clicks par tranche de temps
+	CountClicksGroupedBy(linkID uint, dimension string, limit int, includeBots bool) ([]BreakdownEntry, error)       // Répartition des clicks selon une dimension
+	CountUniqueVisitors(linkID uint, includeBots bool) (int64, error)                                                // Compte les hash de visiteurs distincts des clics bruts
+	SumAggregatedVisitorDays(linkID uint, includeBots bool) (int64, error)                                           // Additionne les visiteurs uniques des jours agrégés
+	ListDailyAggregates(linkID uint, from, to time.Time) ([]models.ClickDailyAggregate, error)                       // Agrégats des jours commençant dans [from, to[
+	ListVisitorsBySlot(linkID uint, from, to time.Time, slot time.Duration, includeBots bool) ([]SlotVisitor, error) // Visiteurs distincts par tranche de temps
+	CountClicksBefore(cutoff time.Time) (int64, error)                                                               // Compte les clicks antérieurs à une date
+	DeleteClicksBefore(cutoff time.Time) (int64, error)                                                              // Supprime les clicks antérieurs à une date
+	AggregateAndDeleteClicksBefore(cutoff time.Time) (int64, error)                                                  // Agrège par jour puis supprime les clicks antérieurs à une date
+}
+
+// SlotVisitor associe un hash de visiteur à une tranche de temps dans laquelle il a cliqué.
+type SlotVisitor struct {
+	Slot        int64
+	VisitorHash string
+}
+
+// Dimensions de répartition acceptées par CountClicksGroupedBy.
+const (
+	DimensionReferrer       = "referrer"
+	DimensionBrowser        = "browser"
+	DimensionBrowserVersion = "browser_version"
+	DimensionOS             = "os"
+	DimensionDevice         = "device"
+)
+
+// breakdownColumns associe chaque dimension à l'expression SQL correspondante.
+// Cette liste blanche empêche toute injection via le nom de dimension.
+var breakdownColumns = map[string]string{
+	DimensionReferrer:       "referrer_domain",
+	DimensionBrowser:        "browser_family",
+	DimensionBrowserVersion: "TRIM(browser_family || ' ' || browser_version)",
+	DimensionOS:             "os",
+	DimensionDevice:         "device_type",
+}
+
+// BreakdownEntry est le nombre de clics pour une valeur d'une dimension (ex: un domaine référent).
+type BreakdownEntry struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+// SlotCount est le nombre de clics enregistrés dans une tranche de temps de durée fixe.
+// Slot est l'index de la tranche depuis l'epoch Unix (timestamp Unix / durée de la tranche).
+type SlotCount struct {
+	Slot   int64
+	Clicks int64
 }
 
 // GormClickRepository est l'implémentation de l'interface ClickRepository utilisant GORM.
@@ -32,7 +81,26 @@ func (r *GormClickRepository) CreateClick(click *models.Click) error {
 	result := r.db.Create(click)
 	if result.Error != nil {
 		return fmt.Errorf("Erreur du click ! Error : %w", result.Error) //Retourne une erreur formatée en cas d'échec
-	} 
+	}
+	return nil
+}
+
+// clickInsertChunk borne le nombre de lignes par requête INSERT, pour rester sous la limite
+// de variables liées de SQLite quelle que soit la taille du lot.
+const clickInsertChunk = 100
+
+// CreateClicks insère un lot de clics dans une seule transaction : soit tous les clics sont
+// enregistrés, soit aucun (le lot peut alors être retenté tel quel).
+func (r *GormClickRepository) CreateClicks(clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(clicks, clickInsertChunk).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert %d clicks: %w", len(clicks), err)
+	}
 	return nil
 }
 
@@ -46,3 +114,202 @@ func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
 	}
 	return int(count), nil
 }
+
+// CountClicksBySlot agrège en SQL les clics d'un lien sur l'intervalle [from, to[ par tranches
+// de durée 'slot' alignées sur l'epoch Unix. Seules les tranches contenant au moins un clic
+// sont retournées, triées par ordre chronologique. Les clics de robots sont exclus sauf si includeBots.
+// Les horodatages sont convertis en secondes Unix par SQLite, ce qui rend l'agrégation
+// indépendante du fuseau horaire dans lequel ils ont été stockés.
+func (r *GormClickRepository) CountClicksBySlot(linkID uint, from, to time.Time, slot time.Duration, includeBots bool) ([]SlotCount, error) {
+	slotSeconds := int64(slot / time.Second)
+	if slotSeconds <= 0 {
+		return nil, fmt.Errorf("invalid slot duration %v", slot)
+	}
+
+	var counts []SlotCount
+	result := r.clicksOf(linkID, includeBots).
+		Select(epochSeconds+" / ? AS slot, COUNT(*) AS clicks", slotSeconds).
+		Where(epochSeconds+" >= ? AND "+epochSeconds+" < ?", from.Unix(), to.Unix()).
+		Group("slot").
+		Order("slot").
+		Scan(&counts)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Erreur lors de l'agrégation des clicks pour le lien %d: %w", linkID, result.Error)
+	}
+	return counts, nil
+}
+
+// epochSeconds convertit en SQL l'horodatage d'un clic en secondes Unix.
+const epochSeconds = "CAST(strftime('%s', timestamp) AS INTEGER)"
+
+// CountClicksGroupedBy retourne les 'limit' valeurs les plus fréquentes d'une dimension pour un lien,
+// triées par nombre de clics décroissant. Les clics de robots sont exclus sauf si includeBots.
+func (r *GormClickRepository) CountClicksGroupedBy(linkID uint, dimension string, limit int, includeBots bool) ([]BreakdownEntry, error) {
+	column, ok := breakdownColumns[dimension]
+	if !ok {
+		return nil, fmt.Errorf("unknown breakdown dimension %q", dimension)
+	}
+
+	// COALESCE regroupe avec les valeurs vides les lignes antérieures à l'ajout de la colonne (NULL).
+	var entries []BreakdownEntry
+	result := r.clicksOf(linkID, includeBots).
+		Select("COALESCE(" + column + ", '') AS value, COUNT(*) AS clicks").
+		Group("value").
+		Order("clicks DESC, value").
+		Limit(limit).
+		Scan(&entries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Erreur lors de la répartition des clicks pour le lien %d: %w", linkID, result.Error)
+	}
+	return entries, nil
+}
+
+// CountUniqueVisitors compte les hash de visiteurs distincts des clics bruts d'un lien. Les hash
+// étant renouvelés chaque jour, un visiteur revenant plusieurs jours est compté une fois par jour.
+// Les clics antérieurs au calcul des hash (hash vide) et les jours déjà agrégés par la politique
+// de rétention sont ignorés (voir SumAggregatedVisitorDays).
+func (r *GormClickRepository) CountUniqueVisitors(linkID uint, includeBots bool) (int64, error) {
+	var count int64
+	result := r.clicksOf(linkID, includeBots).
+		Where("visitor_hash <> ''").
+		Distinct("visitor_hash").
+		Count(&count)
+	if result.Error != nil {
+		return 0, fmt.Errorf("Erreur lors du comptage des visiteurs pour le lien %d: %w", linkID, result.Error)
+	}
+	return count, nil
+}
+
+// SumAggregatedVisitorDays additionne les visiteurs uniques de chaque jour agrégé d'un lien
+// ("visiteurs-jours") : un visiteur présent plusieurs jours est compté une fois par jour, et ce
+// nombre ne doit pas être ajouté à CountUniqueVisitors comme s'il s'agissait de visiteurs distincts.
+// Les agrégats antérieurs au comptage des robots n'ont que les visiteurs humains.
+func (r *GormClickRepository) SumAggregatedVisitorDays(linkID uint, includeBots bool) (int64, error) {
+	column := "unique_visitors"
+	if includeBots {
+		column = "MAX(visitors, unique_visitors)"
+	}
+	var sum int64
+	result := r.db.Model(&models.ClickDailyAggregate{}).
+		Select("COALESCE(SUM("+column+"), 0)").
+		Where("link_id = ?", linkID).
+		Scan(&sum)
+	if result.Error != nil {
+		return 0, fmt.Errorf("Erreur lors du comptage des visiteurs agrégés pour le lien %d: %w", linkID, result.Error)
+	}
+	return sum, nil
+}
+
+// ListDailyAggregates retourne, par date croissante, les agrégats journaliers d'un lien dont le
+// jour (UTC) commence dans l'intervalle [from, to[.
+func (r *GormClickRepository) ListDailyAggregates(linkID uint, from, to time.Time) ([]models.ClickDailyAggregate, error) {
+	var aggregates []models.ClickDailyAggregate
+	result := r.db.Where("link_id = ? AND day >= ? AND day < ?", linkID, dayOf(from), dayOf(to)).
+		Order("day").
+		Find(&aggregates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Erreur lors de la récupération des agrégats du lien %d: %w", linkID, result.Error)
+	}
+	return aggregates, nil
+}
+
+// dayOf retourne la date (UTC, format YYYY-MM-DD) du premier début de jour postérieur ou égal à t.
+func dayOf(t time.Time) string {
+	t = t.UTC()
+	day := t.Truncate(24 * time.Hour)
+	if day.Before(t) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day.Format(time.DateOnly)
+}
+
+// ListVisitorsBySlot retourne les couples distincts (tranche de temps, hash de visiteur) d'un lien
+// sur l'intervalle [from, to[. Contrairement aux clics, les visiteurs uniques ne s'additionnent pas
+// d'une tranche à l'autre : l'appelant doit dédoublonner les hash lors du regroupement en périodes.
+func (r *GormClickRepository) ListVisitorsBySlot(linkID uint, from, to time.Time, slot time.Duration, includeBots bool) ([]SlotVisitor, error) {
+	slotSeconds := int64(slot / time.Second)
+	if slotSeconds <= 0 {
+		return nil, fmt.Errorf("invalid slot duration %v", slot)
+	}
+
+	var visitors []SlotVisitor
+	result := r.clicksOf(linkID, includeBots).
+		Select("DISTINCT "+epochSeconds+" / ? AS slot, visitor_hash", slotSeconds).
+		Where(epochSeconds+" >= ? AND "+epochSeconds+" < ?", from.Unix(), to.Unix()).
+		Where("visitor_hash <> ''").
+		Scan(&visitors)
+	if result.Error != nil {
+		return nil, fmt.Errorf("Erreur lors de la récupération des visiteurs pour le lien %d: %w", linkID, result.Error)
+	}
+	return visitors, nil
+}
+
+// clicksOf prépare une requête sur les clics d'un lien, en excluant ceux des robots si besoin.
+func (r *GormClickRepository) clicksOf(linkID uint, includeBots bool) *gorm.DB {
+	query := r.db.Model(&models.Click{}).Where("link_id = ?", linkID)
+	if !includeBots {
+		query = query.Where("is_bot = ?", false)
+	}
+	return query
+}
+
+// CountClicksBefore compte les clicks enregistrés avant 'cutoff', tous liens confondus.
+func (r *GormClickRepository) CountClicksBefore(cutoff time.Time) (int64, error) {
+	var count int64
+	result := r.db.Model(&models.Click{}).Where(epochSeconds+" < ?", cutoff.Unix()).Count(&count)
+	if result.Error != nil {
+		return 0, fmt.Errorf("Erreur lors du comptage des clicks antérieurs au %s: %w", cutoff.Format(time.RFC3339), result.Error)
+	}
+	return count, nil
+}
+
+// DeleteClicksBefore supprime définitivement les clicks enregistrés avant 'cutoff'
+// et retourne le nombre de lignes supprimées.
+func (r *GormClickRepository) DeleteClicksBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where(epochSeconds+" < ?", cutoff.Unix()).Delete(&models.Click{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("Erreur lors de la suppression des clicks antérieurs au %s: %w", cutoff.Format(time.RFC3339), result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// AggregateAndDeleteClicksBefore ajoute les clicks enregistrés avant 'cutoff' aux compteurs
+// journaliers (table click_daily_aggregates) puis les supprime, dans une même transaction.
+// Pour que les visiteurs uniques restent exacts, 'cutoff' doit tomber sur un début de jour UTC.
+// Un jour peut toutefois être agrégé en deux passes, si des clics rejoués en retard arrivent après
+// la purge : les clics s'additionnent, mais les hash de la première passe étant supprimés, un
+// visiteur présent dans les deux ne peut pas être reconnu. Le plus grand des deux comptes est
+// alors retenu, qui peut sous-estimer les visiteurs du jour mais ne les compte jamais deux fois.
+func (r *GormClickRepository) AggregateAndDeleteClicksBefore(cutoff time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO click_daily_aggregates (link_id, day, clicks, human_clicks, unique_visitors, visitors)
+			SELECT link_id,
+			       date(timestamp) AS day,
+			       COUNT(*),
+			       SUM(CASE WHEN is_bot THEN 0 ELSE 1 END),
+			       COUNT(DISTINCT CASE WHEN NOT is_bot AND visitor_hash <> '' THEN visitor_hash END),
+			       COUNT(DISTINCT CASE WHEN visitor_hash <> '' THEN visitor_hash END)
+			FROM clicks
+			WHERE `+epochSeconds+` < ?
+			GROUP BY link_id, day
+			ON CONFLICT (link_id, day) DO UPDATE SET
+			    clicks = clicks + excluded.clicks,
+			    human_clicks = human_clicks + excluded.human_clicks,
+			    unique_visitors = MAX(unique_visitors, excluded.unique_visitors),
+			    visitors = MAX(visitors, excluded.visitors)`,
+			cutoff.Unix()).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where(epochSeconds+" < ?", cutoff.Unix()).Delete(&models.Click{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Erreur lors de l'agrégation des clicks antérieurs au %s: %w", cutoff.Format(time.RFC3339), err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// LinkCheckRepository est une interface qui définit les méthodes d'accès à l'historique
+// des vérifications effectuées par le moniteur d'URLs.
+type LinkCheckRepository interface {
+	CreateCheck(check *models.LinkCheck) error                           // Enregistre le résultat d'une vérification
+	GetLatestChecks() ([]models.LinkCheck, error)                        // Dernière vérification de chaque lien
+	GetLatestContentChecks() ([]models.LinkCheck, error)                 // Dernière vérification avec empreinte de contenu de chaque lien
+	ListRecentChecks(linkID uint, limit int) ([]models.LinkCheck, error) // Vérifications d'un lien, les plus récentes d'abord
+	CountChecksSince(linkID uint, since time.Time) (CheckCounts, error)  // Nombre de vérifications (et de succès) depuis une date
+	DeleteChecksBefore(cutoff time.Time) (int64, error)                  // Supprime l'historique antérieur à une date
+}
+
+// CheckCounts regroupe le nombre de vérifications d'une période et le nombre de succès.
+type CheckCounts struct {
+	Total      int64
+	Accessible int64
+}
+
+// GormLinkCheckRepository est l'implémentation de l'interface LinkCheckRepository utilisant GORM.
+type GormLinkCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewLinkCheckRepository crée et retourne une nouvelle instance de GormLinkCheckRepository.
+func NewLinkCheckRepository(db *gorm.DB) *GormLinkCheckRepository {
+	return &GormLinkCheckRepository{db: db}
+}
+
+// CreateCheck insère le résultat d'une vérification.
+func (r *GormLinkCheckRepository) CreateCheck(check *models.LinkCheck) error {
+	if err := r.db.Create(check).Error; err != nil {
+		return fmt.Errorf("failed to save link check: %w", err)
+	}
+	return nil
+}
+
+// GetLatestChecks retourne la vérification la plus récente de chaque lien,
+// utilisée pour restaurer l'état du moniteur au démarrage.
+func (r *GormLinkCheckRepository) GetLatestChecks() ([]models.LinkCheck, error) {
+	var checks []models.LinkCheck
+	latest := r.db.Model(&models.LinkCheck{}).Select("MAX(id)").Group("link_id")
+	if err := r.db.Where("id IN (?)", latest).Find(&checks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load latest link checks: %w", err)
+	}
+	return checks, nil
+}
+
+// GetLatestContentChecks retourne, pour chaque lien, la vérification la plus récente portant une
+// empreinte de contenu, utilisée pour restaurer les empreintes du moniteur au démarrage.
+func (r *GormLinkCheckRepository) GetLatestContentChecks() ([]models.LinkCheck, error) {
+	var checks []models.LinkCheck
+	latest := r.db.Model(&models.LinkCheck{}).Select("MAX(id)").Where("content_hash <> ''").Group("link_id")
+	if err := r.db.Where("id IN (?)", latest).Find(&checks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load latest content checks: %w", err)
+	}
+	return checks, nil
+}
+
+// ListRecentChecks retourne au plus 'limit' vérifications d'un lien, de la plus récente à la plus ancienne.
+func (r *GormLinkCheckRepository) ListRecentChecks(linkID uint, limit int) ([]models.LinkCheck, error) {
+	var checks []models.LinkCheck
+	err := r.db.Where("link_id = ?", linkID).Order("checked_at DESC, id DESC").Limit(limit).Find(&checks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link checks: %w", err)
+	}
+	return checks, nil
+}
+
+// CountChecksSince compte les vérifications d'un lien depuis 'since', et celles qui ont réussi.
+func (r *GormLinkCheckRepository) CountChecksSince(linkID uint, since time.Time) (CheckCounts, error) {
+	var counts CheckCounts
+	err := r.db.Model(&models.LinkCheck{}).
+		Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN accessible THEN 1 ELSE 0 END), 0) AS accessible").
+		Where("link_id = ? AND checked_at >= ?", linkID, since.UTC()).
+		Scan(&counts).Error
+	if err != nil {
+		return CheckCounts{}, fmt.Errorf("failed to count link checks: %w", err)
+	}
+	return counts, nil
+}
+
+// DeleteChecksBefore supprime les vérifications antérieures à 'cutoff' et retourne leur nombre.
+func (r *GormLinkCheckRepository) DeleteChecksBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("checked_at < ?", cutoff.UTC()).Delete(&models.LinkCheck{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old link checks: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
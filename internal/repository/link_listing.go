@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// Critères de tri acceptés par ListLinks.
+const (
+	LinkSortCreatedAt = "created_at"
+	LinkSortClicks    = "clicks"
+)
+
+// ErrInvalidCursor est renvoyée lorsqu'un curseur de pagination ne peut pas être décodé.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// LinkListParams décrit une page de liens à récupérer : pagination par curseur, tri et filtres.
+type LinkListParams struct {
+	Limit         int        // Nombre maximal de liens par page
+	Cursor        string     // Curseur opaque renvoyé par la page précédente ; vide = première page
+	SortBy        string     // LinkSortCreatedAt ou LinkSortClicks
+	Ascending     bool       // Ordre croissant (par défaut : décroissant)
+	Search        string     // Sous-chaîne recherchée dans l'URL longue
+	CreatedAfter  *time.Time // Borne inférieure (incluse) de la date de création
+	CreatedBefore *time.Time // Borne supérieure (exclue) de la date de création
+	Status        string     // Un des models.LinkStatus* ; vide = tous les liens non supprimés
+}
+
+// LinkListItem est un lien accompagné de son nombre total de clics.
+type LinkListItem struct {
+	models.Link
+	TotalClicks int64
+}
+
+// LinkPage est une page de résultats de ListLinks.
+// NextCursor est vide lorsqu'il n'y a plus de page suivante.
+type LinkPage struct {
+	Items      []LinkListItem
+	NextCursor string
+}
+
+// linkCursor est le contenu encodé dans un curseur : la valeur de tri et l'ID du
+// dernier lien de la page, l'ID servant à départager les égalités. Le critère et l'ordre
+// de tri pour lesquels le curseur a été émis sont conservés : réutilisé avec un autre tri,
+// il sauterait ou répéterait des liens.
+type linkCursor struct {
+	Value     int64  `json:"v"`
+	ID        uint   `json:"id"`
+	SortBy    string `json:"s"`
+	Ascending bool   `json:"asc,omitempty"`
+}
+
+func encodeLinkCursor(c linkCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeLinkCursor(s string) (linkCursor, error) {
+	var c linkCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ListLinks retourne une page de liens triés et filtrés, sans charger toute la table en mémoire.
+// La pagination se fait par curseur (keyset) sur le couple (valeur de tri, id), ce qui reste
+// stable si des liens sont créés entre deux pages.
+func (r *GormLinkRepository) ListLinks(params LinkListParams) (*LinkPage, error) {
+	sortBy, sortColumn := LinkSortCreatedAt, "l.created_at"
+	if params.SortBy == LinkSortClicks {
+		sortBy, sortColumn = LinkSortClicks, "l.total_clicks"
+	}
+	direction, cmp := "DESC", "<"
+	if params.Ascending {
+		direction, cmp = "ASC", ">"
+	}
+
+	// Sous-requête : liens (y compris supprimés) avec leur nombre de clics calculé en SQL,
+	// clics bruts et clics déjà agrégés par la politique de rétention.
+	clickCount := r.db.Model(&models.Click{}).Select("COUNT(*)").Where("clicks.link_id = links.id")
+	aggregatedCount := r.db.Model(&models.ClickDailyAggregate{}).
+		Select("COALESCE(SUM(clicks), 0)").
+		Where("click_daily_aggregates.link_id = links.id")
+	inner := r.db.Unscoped().Model(&models.Link{}).
+		Select("links.*, (?) + (?) AS total_clicks", clickCount, aggregatedCount)
+
+	query := r.db.Unscoped().Table("(?) AS l", inner)
+
+	// Les dates sont comparées par SQLite en valeurs numériques (julianday) et non en texte :
+	// le format stocké (séparateur, fraction de seconde, fuseau) peut différer de celui du paramètre.
+	now := time.Now().UTC().Format("2006-01-02 15:04:05.000")
+	budgetExhausted := "(l.max_clicks > 0 AND l.used_clicks >= l.max_clicks)"
+	expired := "(l.expires_at IS NOT NULL AND julianday(l.expires_at) <= julianday(?))"
+	switch params.Status {
+	case "":
+		query = query.Where("l.deleted_at IS NULL")
+	case models.LinkStatusDeleted:
+		query = query.Where("l.deleted_at IS NOT NULL")
+	case models.LinkStatusDisabled:
+		query = query.Where("l.deleted_at IS NULL AND l.disabled = ?", true)
+	case models.LinkStatusExpired:
+		query = query.Where("l.deleted_at IS NULL AND l.disabled = ? AND ("+expired+" OR "+budgetExhausted+")", false, now)
+	case models.LinkStatusActive:
+		query = query.Where("l.deleted_at IS NULL AND l.disabled = ? AND NOT "+expired+" AND NOT "+budgetExhausted, false, now)
+	default:
+		return nil, fmt.Errorf("unknown link status %q", params.Status)
+	}
+
+	if params.Search != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(params.Search)
+		query = query.Where(`l.long_url LIKE ? ESCAPE '\'`, "%"+escaped+"%")
+	}
+	if params.CreatedAfter != nil {
+		query = query.Where("l.created_at >= ?", params.CreatedAfter.Unix())
+	}
+	if params.CreatedBefore != nil {
+		query = query.Where("l.created_at < ?", params.CreatedBefore.Unix())
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodeLinkCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != sortBy || cursor.Ascending != params.Ascending {
+			return nil, fmt.Errorf("%w: cursor was issued for another sort or order", ErrInvalidCursor)
+		}
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[2]s ?) OR (%[1]s = ? AND l.id %[2]s ?)", sortColumn, cmp),
+			cursor.Value, cursor.Value, cursor.ID,
+		)
+	}
+
+	// On lit un élément de plus que demandé pour savoir s'il existe une page suivante.
+	var items []LinkListItem
+	err := query.
+		Order(fmt.Sprintf("%s %s, l.id %s", sortColumn, direction, direction)).
+		Limit(params.Limit + 1).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &LinkPage{Items: items}
+	if len(items) > params.Limit {
+		page.Items = items[:params.Limit]
+		last := page.Items[len(page.Items)-1]
+		value := last.CreatedAt
+		if params.SortBy == LinkSortClicks {
+			value = last.TotalClicks
+		}
+		page.NextCursor = encodeLinkCursor(linkCursor{Value: value, ID: last.ID, SortBy: sortBy, Ascending: params.Ascending})
+	}
+	return page, nil
+}
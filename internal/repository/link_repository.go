@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
 )
@@ -17,8 +19,16 @@ func NewLinkRepository(db *gorm.DB) *GormLinkRepository {
 	return &GormLinkRepository{db: db}
 }
 
+// CreateLink insère un nouveau lien. Les violations de contrainte d'unicité sont
+// traduites en gorm.ErrDuplicatedKey pour que les appelants puissent les détecter.
 func (r *GormLinkRepository) CreateLink(link *models.Link) error {
-	return r.db.Create(link).Error
+	err := r.db.Create(link).Error
+	if err != nil {
+		if translator, ok := r.db.Dialector.(gorm.ErrorTranslator); ok {
+			return translator.Translate(err)
+		}
+	}
+	return err
 }
 
 func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
@@ -43,13 +53,45 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 	return links, nil
 }
 
+// CountClicksByLinkID compte tous les clics d'un lien, y compris ceux déjà agrégés
+// par la politique de rétention.
 func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
 	var count int64
 	err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error
 	if err != nil {
 		return 0, err
 	}
-	return int(count), nil
+	aggregated, err := r.sumAggregatedClicks(linkID, "clicks")
+	if err != nil {
+		return 0, err
+	}
+	return int(count + aggregated), nil
+}
+
+// CountHumanClicksByLinkID compte les clics d'un lien qui n'ont pas été attribués à un robot,
+// y compris ceux déjà agrégés par la politique de rétention.
+func (r *GormLinkRepository) CountHumanClicksByLinkID(linkID uint) (int, error) {
+	var count int64
+	err := r.db.Model(&models.Click{}).Where("link_id = ? AND is_bot = ?", linkID, false).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	aggregated, err := r.sumAggregatedClicks(linkID, "human_clicks")
+	if err != nil {
+		return 0, err
+	}
+	return int(count + aggregated), nil
+}
+
+// sumAggregatedClicks additionne une colonne de compteur (clicks ou human_clicks) des
+// agrégats journaliers d'un lien.
+func (r *GormLinkRepository) sumAggregatedClicks(linkID uint, column string) (int64, error) {
+	var sum int64
+	err := r.db.Model(&models.ClickDailyAggregate{}).
+		Select("COALESCE(SUM("+column+"), 0)").
+		Where("link_id = ?", linkID).
+		Scan(&sum).Error
+	return sum, err
 }
 
 func (r *GormLinkRepository) GetLinkByID(id uint) (*models.Link, error) {
@@ -61,11 +103,90 @@ func (r *GormLinkRepository) GetLinkByID(id uint) (*models.Link, error) {
 	return &link, nil
 }
 
+// ConsumeClick consomme atomiquement un clic du budget d'un lien.
+// La condition est évaluée dans la même requête UPDATE que l'incrément, de sorte que
+// des redirections concurrentes ne peuvent pas dépasser MaxClicks.
+// Retourne false si le budget est déjà épuisé.
+func (r *GormLinkRepository) ConsumeClick(linkID uint) (bool, error) {
+	result := r.db.Model(&models.Link{}).
+		Where("id = ? AND used_clicks < max_clicks", linkID).
+		UpdateColumn("used_clicks", gorm.Expr("used_clicks + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ShortCodeExists indique si un code court est déjà attribué, y compris à un lien
+// supprimé logiquement : l'index unique de la colonne s'applique aussi à ces lignes.
+func (r *GormLinkRepository) ShortCodeExists(shortCode string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Link{}).Where("short_code = ?", shortCode).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// UpdateLink met à jour les colonnes fournies d'un lien existant.
+// Seules les clés présentes dans 'fields' sont modifiées, ce qui évite d'écraser
+// les compteurs mis à jour en parallèle (ex: used_clicks).
+func (r *GormLinkRepository) UpdateLink(linkID uint, fields map[string]interface{}) error {
+	return r.db.Model(&models.Link{ID: linkID}).Updates(fields).Error
+}
+
+// SetAutoDisabled désactive un lien actif (disabled=true) ou réactive un lien désactivé par le
+// moniteur (disabled=false). La condition fait partie de la requête : un lien désactivé ou
+// réactivé manuellement entre-temps n'est pas modifié. Retourne true si le lien a changé.
+func (r *GormLinkRepository) SetAutoDisabled(linkID uint, disabled bool, reason string, at time.Time) (bool, error) {
+	query := r.db.Model(&models.Link{}).Where("id = ?", linkID)
+	var fields map[string]interface{}
+	if disabled {
+		query = query.Where("disabled = ?", false)
+		fields = map[string]interface{}{"disabled": true, "auto_disabled": true, "disabled_at": at.UTC(), "disabled_reason": reason}
+	} else {
+		query = query.Where("auto_disabled = ?", true)
+		fields = map[string]interface{}{"disabled": false, "auto_disabled": false, "disabled_at": nil, "disabled_reason": ""}
+	}
+	result := query.Updates(fields)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteLink supprime logiquement un lien (renseigne deleted_at).
+func (r *GormLinkRepository) DeleteLink(linkID uint) error {
+	return r.db.Delete(&models.Link{}, linkID).Error
+}
+
+// RestoreLink annule la suppression logique d'un lien et le retourne.
+// Retourne gorm.ErrRecordNotFound si aucun lien supprimé ne correspond au code court.
+func (r *GormLinkRepository) RestoreLink(shortCode string) (*models.Link, error) {
+	var link models.Link
+	err := r.db.Unscoped().Where("short_code = ? AND deleted_at IS NOT NULL", shortCode).First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.Unscoped().Model(&link).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	link.DeletedAt = gorm.DeletedAt{}
+	return &link, nil
+}
 
 type LinkRepository interface {
 	GetAllLinks() ([]models.Link, error)
-    CreateLink(link *models.Link) error
-    GetLinkByShortCode(shortCode string) (*models.Link, error)
-    GetLinkByID(id uint) (*models.Link, error)
+	CreateLink(link *models.Link) error
+	GetLinkByShortCode(shortCode string) (*models.Link, error)
+	GetLinkByID(id uint) (*models.Link, error)
 	CountClicksByLinkID(linkID uint) (int, error)
-}
\ No newline at end of file
+	CountHumanClicksByLinkID(linkID uint) (int, error)
+	ConsumeClick(linkID uint) (bool, error)
+	ShortCodeExists(shortCode string) (bool, error)
+	UpdateLink(linkID uint, fields map[string]interface{}) error
+	SetAutoDisabled(linkID uint, disabled bool, reason string, at time.Time) (bool, error)
+	DeleteLink(linkID uint) error
+	RestoreLink(shortCode string) (*models.Link, error)
+	ListLinks(params LinkListParams) (*LinkPage, error)
+}
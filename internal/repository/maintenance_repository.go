@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// MaintenanceWindowRepository est une interface qui définit les méthodes d'accès aux fenêtres
+// de maintenance du moniteur.
+type MaintenanceWindowRepository interface {
+	CreateWindow(window *models.MaintenanceWindow) error                          // Enregistre une fenêtre
+	ListWindows(endingAfter time.Time) ([]models.MaintenanceWindow, error)        // Fenêtres non terminées à une date, par date de début
+	GetActiveWindow(linkID uint, at time.Time) (*models.MaintenanceWindow, error) // Fenêtre couvrant un lien à une date (nil si aucune)
+	DeleteWindow(id uint) error                                                   // Supprime une fenêtre
+}
+
+// GormMaintenanceWindowRepository est l'implémentation de l'interface MaintenanceWindowRepository utilisant GORM.
+type GormMaintenanceWindowRepository struct {
+	db *gorm.DB
+}
+
+// NewMaintenanceWindowRepository crée et retourne une nouvelle instance de GormMaintenanceWindowRepository.
+func NewMaintenanceWindowRepository(db *gorm.DB) *GormMaintenanceWindowRepository {
+	return &GormMaintenanceWindowRepository{db: db}
+}
+
+// CreateWindow insère une fenêtre de maintenance.
+func (r *GormMaintenanceWindowRepository) CreateWindow(window *models.MaintenanceWindow) error {
+	if err := r.db.Create(window).Error; err != nil {
+		return fmt.Errorf("failed to save maintenance window: %w", err)
+	}
+	return nil
+}
+
+// ListWindows retourne les fenêtres qui se terminent après 'endingAfter' (en cours ou à venir ;
+// toutes avec une date nulle), avec leur lien.
+func (r *GormMaintenanceWindowRepository) ListWindows(endingAfter time.Time) ([]models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	err := r.db.Preload("Link").Where("ends_at > ?", endingAfter.UTC()).Order("starts_at, id").Find(&windows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance windows: %w", err)
+	}
+	return windows, nil
+}
+
+// GetActiveWindow retourne une fenêtre propre au lien ou globale couvrant 'at', nil s'il n'y en a pas.
+// Parmi plusieurs fenêtres, celle qui se termine le plus tard est retournée.
+func (r *GormMaintenanceWindowRepository) GetActiveWindow(linkID uint, at time.Time) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	err := r.db.Where("(link_id = ? OR link_id IS NULL) AND starts_at <= ? AND ends_at > ?", linkID, at.UTC(), at.UTC()).
+		Order("ends_at DESC").First(&window).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find active maintenance window: %w", err)
+	}
+	return &window, nil
+}
+
+// DeleteWindow supprime une fenêtre. Retourne gorm.ErrRecordNotFound si elle n'existe pas.
+func (r *GormMaintenanceWindowRepository) DeleteWindow(id uint) error {
+	result := r.db.Delete(&models.MaintenanceWindow{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
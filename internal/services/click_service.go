@@ -1,7 +1,9 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
@@ -66,3 +68,259 @@ func (s *ClickService) GetClicksCountByLinkID(linkID uint) (int, error) {
 	}
 	return cnt, nil
 }
+
+// emptyDimensionLabels donne le libellé affiché pour une valeur vide de chaque dimension.
+var emptyDimensionLabels = map[string]string{
+	repository.DimensionReferrer:       "(direct)",
+	repository.DimensionBrowser:        "unknown",
+	repository.DimensionBrowserVersion: "unknown",
+	repository.DimensionOS:             "unknown",
+	repository.DimensionDevice:         "unknown",
+}
+
+// GetClickBreakdown retourne la répartition des clics d'un lien selon une dimension
+// (voir les constantes repository.Dimension*), limitée aux 'limit' valeurs les plus fréquentes.
+// Les clics de robots ne sont comptés que si includeBots est vrai.
+func (s *ClickService) GetClickBreakdown(linkID uint, dimension string, limit int, includeBots bool) ([]repository.BreakdownEntry, error) {
+	if s == nil || s.clickRepo == nil {
+		return nil, fmt.Errorf("⚠️  service ClickService non initialisé")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	entries, err := s.clickRepo.CountClicksGroupedBy(linkID, dimension, limit, includeBots)
+	if err != nil {
+		return nil, fmt.Errorf("✗ impossible de récupérer la répartition '%s' pour LinkID=%d : %w", dimension, linkID, err)
+	}
+	for i := range entries {
+		if entries[i].Value == "" {
+			entries[i].Value = emptyDimensionLabels[dimension]
+		}
+	}
+	return entries, nil
+}
+
+// Modes de la politique de rétention des clics bruts (analytics.privacy.retention_mode).
+const (
+	RetentionDelete    = "delete"    // Les clics trop anciens sont supprimés
+	RetentionAggregate = "aggregate" // Les clics trop anciens sont résumés en compteurs journaliers puis supprimés
+)
+
+// ErrInvalidRetention est renvoyée lorsque les paramètres de purge sont invalides.
+var ErrInvalidRetention = errors.New("invalid retention policy")
+
+// RetentionCutoff retourne la date avant laquelle les clics doivent être purgés pour une
+// rétention de 'days' jours. Elle est alignée sur un début de jour UTC afin qu'un jour soit
+// agrégé en une seule passe (sauf clics rejoués en retard, voir AggregateAndDeleteClicksBefore).
+func RetentionCutoff(now time.Time, days int) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d-days, 0, 0, 0, 0, time.UTC)
+}
+
+// PurgeClicks applique la politique de rétention : les clics bruts antérieurs à RetentionCutoff
+// sont supprimés (mode "delete") ou agrégés par jour puis supprimés (mode "aggregate").
+// Avec dryRun, rien n'est modifié et le nombre de clics concernés est retourné.
+func (s *ClickService) PurgeClicks(retentionDays int, mode string, dryRun bool) (int64, time.Time, error) {
+	if s == nil || s.clickRepo == nil {
+		return 0, time.Time{}, fmt.Errorf("⚠️  service ClickService non initialisé")
+	}
+	if retentionDays < 1 {
+		return 0, time.Time{}, fmt.Errorf("%w: retention must be at least 1 day", ErrInvalidRetention)
+	}
+	if mode != RetentionDelete && mode != RetentionAggregate {
+		return 0, time.Time{}, fmt.Errorf("%w: unknown mode %q (expected delete or aggregate)", ErrInvalidRetention, mode)
+	}
+
+	cutoff := RetentionCutoff(time.Now(), retentionDays)
+
+	var count int64
+	var err error
+	switch {
+	case dryRun:
+		count, err = s.clickRepo.CountClicksBefore(cutoff)
+	case mode == RetentionAggregate:
+		count, err = s.clickRepo.AggregateAndDeleteClicksBefore(cutoff)
+	default:
+		count, err = s.clickRepo.DeleteClicksBefore(cutoff)
+	}
+	if err != nil {
+		return 0, cutoff, fmt.Errorf("✗ impossible de purger les clics antérieurs au %s : %w", cutoff.Format(time.DateOnly), err)
+	}
+	return count, cutoff, nil
+}
+
+// Granularités acceptées pour les séries temporelles de clics.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// timeSeriesSlot est la granularité de l'agrégation SQL. Tous les fuseaux horaires réels ont
+// un décalage multiple de 15 minutes par rapport à UTC : des tranches de 15 minutes peuvent
+// donc toujours être regroupées exactement en heures, jours ou semaines locales.
+const timeSeriesSlot = 15 * time.Minute
+
+// maxTimeSeriesBuckets limite la taille d'une série pour éviter des réponses démesurées.
+const maxTimeSeriesBuckets = 2000
+
+// ErrInvalidTimeSeries est renvoyée lorsque les paramètres d'une série temporelle sont invalides.
+var ErrInvalidTimeSeries = errors.New("invalid time series query")
+
+// TimeBucket est le nombre de clics et de visiteurs uniques d'une période de la série temporelle.
+type TimeBucket struct {
+	Start          time.Time `json:"start"`
+	Clicks         int64     `json:"clicks"`
+	UniqueVisitors int64     `json:"unique_visitors"`
+}
+
+// GetClickTimeSeries retourne le nombre de clics et de visiteurs uniques d'un lien par heure, jour ou semaine (semaines
+// commençant le lundi) sur l'intervalle [from, to[, les périodes étant calculées dans le fuseau 'loc'.
+// Les périodes sans clic sont incluses avec un compte nul. Les clics de robots ne sont comptés
+// que si includeBots est vrai. Les jours déjà agrégés par la politique de rétention sont comptés
+// en entier dans la période qui contient leur début (minuit UTC).
+func (s *ClickService) GetClickTimeSeries(linkID uint, from, to time.Time, interval string, loc *time.Location, includeBots bool) ([]TimeBucket, error) {
+	if s == nil || s.clickRepo == nil {
+		return nil, fmt.Errorf("⚠️  service ClickService non initialisé")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: 'from' must be before 'to'", ErrInvalidTimeSeries)
+	}
+	switch interval {
+	case IntervalHour, IntervalDay, IntervalWeek:
+	default:
+		return nil, fmt.Errorf("%w: unknown interval %q", ErrInvalidTimeSeries, interval)
+	}
+
+	// Prépare toutes les périodes de l'intervalle demandé, y compris celles sans clic.
+	var buckets []TimeBucket
+	index := make(map[int64]int)
+	for start := truncateToInterval(from.In(loc), interval); start.Before(to); start = nextInterval(start, interval) {
+		if len(buckets) >= maxTimeSeriesBuckets {
+			return nil, fmt.Errorf("%w: more than %d buckets, use a larger interval", ErrInvalidTimeSeries, maxTimeSeriesBuckets)
+		}
+		index[start.Unix()] = len(buckets)
+		buckets = append(buckets, TimeBucket{Start: start})
+	}
+
+	slots, err := s.clickRepo.CountClicksBySlot(linkID, from, to, timeSeriesSlot, includeBots)
+	if err != nil {
+		return nil, fmt.Errorf("✗ impossible d'agréger les clics pour LinkID=%d : %w", linkID, err)
+	}
+
+	// Regroupe les tranches de 15 minutes dans leur période locale.
+	bucketOf := func(slot int64) (int, bool) {
+		slotStart := time.Unix(slot*int64(timeSeriesSlot/time.Second), 0).In(loc)
+		i, ok := index[truncateToInterval(slotStart, interval).Unix()]
+		return i, ok
+	}
+	for _, slot := range slots {
+		if i, ok := bucketOf(slot.Slot); ok {
+			buckets[i].Clicks += slot.Clicks
+		}
+	}
+
+	// Les visiteurs uniques sont dédoublonnés par période : un visiteur présent dans plusieurs
+	// tranches de 15 minutes d'une même période n'est compté qu'une fois.
+	visitors, err := s.clickRepo.ListVisitorsBySlot(linkID, from, to, timeSeriesSlot, includeBots)
+	if err != nil {
+		return nil, fmt.Errorf("✗ impossible de compter les visiteurs pour LinkID=%d : %w", linkID, err)
+	}
+	seen := make([]map[string]struct{}, len(buckets))
+	for _, v := range visitors {
+		i, ok := bucketOf(v.Slot)
+		if !ok {
+			continue
+		}
+		if seen[i] == nil {
+			seen[i] = make(map[string]struct{})
+		}
+		seen[i][v.VisitorHash] = struct{}{}
+	}
+	for i := range buckets {
+		buckets[i].UniqueVisitors = int64(len(seen[i]))
+	}
+
+	// Les hash de visiteurs étant propres à chaque jour, les visiteurs d'un jour agrégé
+	// s'ajoutent à ceux des autres jours de la période sans doublon possible.
+	aggregates, err := s.clickRepo.ListDailyAggregates(linkID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("✗ impossible de récupérer les jours agrégés pour LinkID=%d : %w", linkID, err)
+	}
+	for _, aggregate := range aggregates {
+		day, err := time.Parse(time.DateOnly, aggregate.Day)
+		if err != nil {
+			continue
+		}
+		i, ok := index[truncateToInterval(day.In(loc), interval).Unix()]
+		if !ok {
+			continue
+		}
+		if includeBots {
+			buckets[i].Clicks += aggregate.Clicks
+			buckets[i].UniqueVisitors += max(aggregate.Visitors, aggregate.UniqueVisitors)
+		} else {
+			buckets[i].Clicks += aggregate.HumanClicks
+			buckets[i].UniqueVisitors += aggregate.UniqueVisitors
+		}
+	}
+	return buckets, nil
+}
+
+// GetUniqueVisitors retourne le nombre de visiteurs uniques d'un lien (hash distincts des clics bruts).
+// Les hash étant renouvelés chaque jour, un visiteur revenant plusieurs jours est compté une fois par jour.
+func (s *ClickService) GetUniqueVisitors(linkID uint, includeBots bool) (int64, error) {
+	if s == nil || s.clickRepo == nil {
+		return 0, fmt.Errorf("⚠️  service ClickService non initialisé")
+	}
+	count, err := s.clickRepo.CountUniqueVisitors(linkID, includeBots)
+	if err != nil {
+		return 0, fmt.Errorf("✗ impossible de compter les visiteurs pour LinkID=%d : %w", linkID, err)
+	}
+	return count, nil
+}
+
+// GetAggregatedVisitorDays retourne la somme des visiteurs uniques des jours agrégés par la
+// politique de rétention. C'est un nombre de visiteurs-jours, distinct de GetUniqueVisitors.
+func (s *ClickService) GetAggregatedVisitorDays(linkID uint, includeBots bool) (int64, error) {
+	if s == nil || s.clickRepo == nil {
+		return 0, fmt.Errorf("⚠️  service ClickService non initialisé")
+	}
+	count, err := s.clickRepo.SumAggregatedVisitorDays(linkID, includeBots)
+	if err != nil {
+		return 0, fmt.Errorf("✗ impossible de compter les visiteurs agrégés pour LinkID=%d : %w", linkID, err)
+	}
+	return count, nil
+}
+
+// truncateToInterval ramène t au début de sa période (heure, jour ou semaine) dans son fuseau.
+func truncateToInterval(t time.Time, interval string) time.Time {
+	y, m, d := t.Date()
+	switch interval {
+	case IntervalHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case IntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7 // lundi = 0
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextInterval retourne le début de la période suivant celle qui commence à 'start'.
+// Les jours et semaines sont calculés en calendrier local pour rester corrects lors
+// des changements d'heure.
+func nextInterval(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return start.Add(time.Hour)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// États de santé d'un lien, déduits de la dernière vérification du moniteur (seuil d'échecs consécutifs compris).
+const (
+	HealthStatusUp      = "up"
+	HealthStatusDown    = "down"
+	HealthStatusUnknown = "unknown" // Le lien n'a pas encore été vérifié
+)
+
+// ErrInvalidHealthQuery est retournée lorsque la fenêtre ou la taille d'historique demandée est invalide.
+var ErrInvalidHealthQuery = errors.New("invalid health query")
+
+// Limites acceptées pour GetLinkHealth.
+const (
+	MaxHealthWindow       = 90 * 24 * time.Hour
+	MaxHealthHistoryLimit = 100
+)
+
+// LinkHealth résume l'état d'un lien tel qu'observé par le moniteur.
+type LinkHealth struct {
+	Status    string
+	LastCheck *models.LinkCheck // nil si le lien n'a jamais été vérifié
+	Window    time.Duration     // Période sur laquelle Uptime est calculé
+	Checks    int64             // Nombre de vérifications dans la période
+	Uptime    *float64          // Pourcentage de vérifications réussies dans la période, nil sans vérification
+	History   []models.LinkCheck
+}
+
+// LinkHealthService fournit l'historique de santé des liens enregistré par le moniteur.
+type LinkHealthService struct {
+	checkRepo repository.LinkCheckRepository
+}
+
+// NewLinkHealthService crée et retourne une nouvelle instance de LinkHealthService.
+func NewLinkHealthService(checkRepo repository.LinkCheckRepository) *LinkHealthService {
+	return &LinkHealthService{checkRepo: checkRepo}
+}
+
+// GetLinkHealth retourne l'état courant d'un lien, son taux de disponibilité sur 'window'
+// et ses 'historyLimit' dernières vérifications.
+func (s *LinkHealthService) GetLinkHealth(linkID uint, window time.Duration, historyLimit int) (*LinkHealth, error) {
+	if window <= 0 || window > MaxHealthWindow {
+		return nil, fmt.Errorf("%w: window must be between 1h and %dh", ErrInvalidHealthQuery, int(MaxHealthWindow.Hours()))
+	}
+	if historyLimit < 1 || historyLimit > MaxHealthHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidHealthQuery, MaxHealthHistoryLimit)
+	}
+
+	history, err := s.checkRepo.ListRecentChecks(linkID, historyLimit)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.checkRepo.CountChecksSince(linkID, time.Now().Add(-window))
+	if err != nil {
+		return nil, err
+	}
+
+	health := &LinkHealth{
+		Status:  HealthStatusUnknown,
+		Window:  window,
+		Checks:  counts.Total,
+		History: history,
+	}
+	if len(history) > 0 {
+		health.LastCheck = &history[0]
+		health.Status = HealthStatusDown
+		if history[0].IsUp() {
+			health.Status = HealthStatusUp
+		}
+	}
+	if counts.Total > 0 {
+		uptime := float64(counts.Accessible) * 100 / float64(counts.Total)
+		health.Uptime = &uptime
+	}
+	return health, nil
+}
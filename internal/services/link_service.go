@@ -6,22 +6,93 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"net/url"
+	"strings"
 	"time"
 
 	"gorm.io/gorm" // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
 
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
 )
 
 // Définition du jeu de caractères pour la génération des codes courts.
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+// Bornes de longueur d'un alias personnalisé. Le maximum correspond à la taille
+// de la colonne ShortCode (size:10) du modèle Link.
+const (
+	minAliasLength = 3
+	maxAliasLength = 10
+)
+
+// reservedAliases liste les codes qui entreraient en conflit avec les routes de l'API.
+// La comparaison est insensible à la casse.
+var reservedAliases = map[string]struct{}{
+	"health":  {},
+	"links":   {},
+	"metrics": {},
+}
+
+// Erreurs métier renvoyées par CreateLink, détectables avec errors.Is.
+var (
+	ErrInvalidAlias    = errors.New("invalid alias")
+	ErrAliasTaken      = errors.New("alias already in use")
+	ErrInvalidLifetime = errors.New("invalid link lifetime")
+)
+
+// Erreurs renvoyées par ResolveRedirect et ConsumeClick lorsque le lien existe mais ne doit plus rediriger.
+var (
+	ErrLinkExpired          = errors.New("link expired")
+	ErrClickBudgetExhausted = errors.New("click budget exhausted")
+	ErrLinkDisabled         = errors.New("link disabled")
+)
+
+// Bornes de la taille d'une page de ListLinks.
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ErrInvalidListQuery est renvoyée par ListLinks lorsqu'un paramètre de tri, de filtre
+// ou de pagination n'est pas valide.
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+// ErrNothingToUpdate est renvoyée par UpdateLink lorsqu'aucun champ n'est fourni.
+var ErrNothingToUpdate = errors.New("nothing to update")
+
+// ErrInvalidFallbackURL est renvoyée lorsque l'URL de repli d'un lien n'est pas une URL HTTP(S) absolue.
+var ErrInvalidFallbackURL = errors.New("invalid fallback url")
+
+// ErrInvalidMonitorSettings est renvoyée par UpdateLink lorsqu'un réglage de surveillance n'est pas valide.
+var ErrInvalidMonitorSettings = errors.New("invalid monitor settings")
+
+// ErrForbiddenDestination est renvoyée par CreateLink et UpdateLink lorsque la destination
+// désigne un réseau privé refusé par la politique d'URL (url_policy).
+var ErrForbiddenDestination = errors.New("destination not allowed")
+
+// Bornes des réglages de surveillance propres à un lien.
+const (
+	maxFailureThreshold = 100
+	maxUserAgentLength  = 255
+	maxIntervalMinutes  = 7 * 24 * 60
+)
+
+// CreateLinkOptions regroupe les paramètres optionnels de la création d'un lien.
+type CreateLinkOptions struct {
+	Alias       string     // Code court choisi par l'utilisateur ; vide = code généré aléatoirement
+	ExpiresAt   *time.Time // Date d'expiration ; nil = pas d'expiration
+	MaxClicks   int        // Nombre maximal de redirections ; 0 = illimité
+	FallbackURL string     // URL de repli tant que la destination est inaccessible ; vide = repli global
+}
+
 // LinkService est une structure qui fournit des méthodes pour la logique métier des liens.
 // Elle détient linkRepo qui est une référence vers une interface LinkRepository.
 // IMPORTANT : Le champ doit être du type de l'interface (non-pointeur).
 type LinkService struct {
-	linkRepo repository.LinkRepository
+	linkRepo  repository.LinkRepository
+	urlPolicy *urlpolicy.Policy // Contrôle des destinations privées ; nil = aucun contrôle
 }
 
 // NewLinkService crée et retourne une nouvelle instance de LinkService.
@@ -31,6 +102,29 @@ func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
 	}
 }
 
+// WithURLPolicy active le contrôle des destinations de CreateLink et UpdateLink.
+func (s *LinkService) WithURLPolicy(policy *urlpolicy.Policy) *LinkService {
+	s.urlPolicy = policy
+	return s
+}
+
+// checkDestination applique la politique d'URL à une destination. Elle retourne
+// ErrForbiddenDestination si la destination est refusée, et indique sinon si elle doit être
+// signalée comme privée (mode "flag").
+func (s *LinkService) checkDestination(longURL string) (bool, error) {
+	if s.urlPolicy == nil {
+		return false, nil
+	}
+	err := s.urlPolicy.CheckURL(longURL)
+	if err == nil {
+		return false, nil
+	}
+	if errors.Is(err, urlpolicy.ErrPrivateDestination) && s.urlPolicy.Mode() == urlpolicy.ModeFlag {
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: %v", ErrForbiddenDestination, err)
+}
+
 func (s *LinkService) GenerateShortCode(length int) (string, error) {
 	code := make([]byte, length)
 	for i := range code {
@@ -43,74 +137,414 @@ func (s *LinkService) GenerateShortCode(length int) (string, error) {
 	return string(code), nil
 }
 
+// ValidateAlias vérifie qu'un alias personnalisé peut être utilisé comme code court :
+// caractères du charset uniquement, longueur bornée et nom non réservé.
+func ValidateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength {
+		return fmt.Errorf("%w: length must be between %d and %d characters", ErrInvalidAlias, minAliasLength, maxAliasLength)
+	}
+	for _, r := range alias {
+		if !strings.ContainsRune(charset, r) {
+			return fmt.Errorf("%w: only letters and digits are allowed", ErrInvalidAlias)
+		}
+	}
+	if _, reserved := reservedAliases[strings.ToLower(alias)]; reserved {
+		return fmt.Errorf("%w: '%s' is reserved", ErrInvalidAlias, alias)
+	}
+	return nil
+}
 
 // CreateLink crée un nouveau lien raccourci.
-// Il génère un code court unique, puis persiste le lien dans la base de données.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
+// Si opts.Alias est renseigné, il est validé puis utilisé tel quel comme code court ;
+// sinon un code court unique est généré. Le lien est ensuite persisté en base.
+func (s *LinkService) CreateLink(longURL string, opts CreateLinkOptions) (*models.Link, error) {
+	if opts.ExpiresAt != nil && !opts.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiration date must be in the future", ErrInvalidLifetime)
+	}
+	if opts.MaxClicks < 0 {
+		return nil, fmt.Errorf("%w: max clicks must be positive", ErrInvalidLifetime)
+	}
+	if opts.FallbackURL != "" {
+		if err := validateFallbackURL(opts.FallbackURL); err != nil {
+			return nil, err
+		}
+	}
+	privateDestination, err := s.checkDestination(longURL)
+	if err != nil {
+		return nil, err
+	}
+
 	var shortCode string
-	var err error
+	if opts.Alias != "" {
+		shortCode, err = s.reserveAlias(opts.Alias)
+	} else {
+		shortCode, err = s.generateUniqueShortCode()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Les dates d'expiration sont stockées en UTC pour pouvoir être comparées en SQL.
+	if opts.ExpiresAt != nil {
+		expiresAt := opts.ExpiresAt.UTC()
+		opts.ExpiresAt = &expiresAt
+	}
+
+	// Store the short code without a leading slash. Route/handlers can add the slash
+	// when building full URLs to avoid double-slash issues.
+	link := &models.Link{
+		LongURL:            longURL,
+		ShortCode:          shortCode,
+		CreatedAt:          time.Now().Unix(),
+		ExpiresAt:          opts.ExpiresAt,
+		MaxClicks:          opts.MaxClicks,
+		FallbackURL:        opts.FallbackURL,
+		PrivateDestination: privateDestination,
+	}
+
+	if err := s.linkRepo.CreateLink(link); err != nil {
+		// Un autre appel a pu prendre l'alias entre la vérification et l'insertion.
+		if opts.Alias != "" && errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrAliasTaken
+		}
+		return nil, fmt.Errorf("error creating link in database: %w", err)
+	}
+	return link, nil
+}
+
+// validateFallbackURL vérifie qu'une URL de repli est une URL HTTP(S) absolue.
+func validateFallbackURL(rawURL string) error {
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q must be an absolute http(s) url", ErrInvalidFallbackURL, rawURL)
+	}
+	return nil
+}
+
+// reserveAlias valide l'alias demandé et vérifie qu'il n'est pas déjà utilisé.
+func (s *LinkService) reserveAlias(alias string) (string, error) {
+	if err := ValidateAlias(alias); err != nil {
+		return "", err
+	}
+
+	exists, err := s.linkRepo.ShortCodeExists(alias)
+	if err != nil {
+		return "", fmt.Errorf("database error checking alias availability: %w", err)
+	}
+	if exists {
+		return "", ErrAliasTaken
+	}
+	return alias, nil
+}
+
+// generateUniqueShortCode génère un code court aléatoire de 6 caractères
+// en réessayant en cas de collision avec un code existant.
+func (s *LinkService) generateUniqueShortCode() (string, error) {
 	const maxRetries = 5
-	var unique bool
 	for i := 0; i < maxRetries; i++ {
-		shortCode, err = s.GenerateShortCode(6)
+		shortCode, err := s.GenerateShortCode(6)
 		if err != nil {
-			return nil, fmt.Errorf("error generating short code: %w", err)
+			return "", fmt.Errorf("error generating short code: %w", err)
 		}
 
-		_, err = s.linkRepo.GetLinkByShortCode(shortCode)
-		if err == nil {
+		exists, err := s.linkRepo.ShortCodeExists(shortCode)
+		if err != nil {
+			// unexpected database error
+			return "", fmt.Errorf("database error checking short code uniqueness: %w", err)
+		}
+		if exists {
 			// Le code court existe déjà, générer un nouveau
 			log.Printf("Le code court '%s' existe déjà, nouvelle génération (%d/%d)...", shortCode, i+1, maxRetries)
 			continue
 		}
 
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// not found -> unique
-			unique = true
-			break
-		}
+		return shortCode, nil
+	}
+
+	return "", errors.New("failed to generate a unique short code after maximum retries")
+}
+
+// GetLinkByShortCode récupère un lien via son code court.
+// Il délègue l'opération de recherche au repository.
+func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	return s.linkRepo.GetLinkByShortCode(shortCode)
+}
 
-		// unexpected database error
-		return nil, fmt.Errorf("database error checking short code uniqueness: %w", err)
+// ResolveRedirect récupère le lien vers lequel rediriger pour un code court.
+// Il vérifie que le lien est actif, n'a pas expiré et que son budget de clics n'est pas épuisé,
+// sans consommer de clic : voir ConsumeClick.
+// Retourne ErrLinkDisabled, ErrLinkExpired ou ErrClickBudgetExhausted si le lien ne doit plus rediriger.
+func (s *LinkService) ResolveRedirect(shortCode string) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
 	}
 
-	if !unique {
-		return nil, errors.New("failed to generate a unique short code after maximum retries")
+	if link.Disabled {
+		return link, ErrLinkDisabled
 	}
 
-	// Store the short code without a leading slash. Route/handlers can add the slash
-	// when building full URLs to avoid double-slash issues.
-	link := &models.Link{
-		LongURL:   longURL,
-		ShortCode: shortCode,
-		CreatedAt: time.Now().Unix(),
+	if link.IsExpired(time.Now()) {
+		return link, ErrLinkExpired
 	}
 
-	if err := s.linkRepo.CreateLink(link); err != nil {
-		return nil, fmt.Errorf("error creating link in database: %w", err)
+	if link.MaxClicks > 0 && link.UsedClicks >= link.MaxClicks {
+		return link, ErrClickBudgetExhausted
 	}
+
 	return link, nil
 }
 
-// GetLinkByShortCode récupère un lien via son code court.
-// Il délègue l'opération de recherche au repository.
-func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
-	return s.linkRepo.GetLinkByShortCode(shortCode)
+// ConsumeClick consomme atomiquement un clic du budget d'un lien résolu par ResolveRedirect.
+// Il n'est appelé que pour les visites humaines : les requêtes HEAD et les robots (aperçus de
+// liens, crawlers) n'entament pas le budget. Retourne ErrClickBudgetExhausted si un autre
+// visiteur a consommé le dernier clic entre-temps.
+func (s *LinkService) ConsumeClick(link *models.Link) error {
+	if link.MaxClicks <= 0 {
+		return nil
+	}
+	consumed, err := s.linkRepo.ConsumeClick(link.ID)
+	if err != nil {
+		return fmt.Errorf("error consuming click budget: %w", err)
+	}
+	if !consumed {
+		return ErrClickBudgetExhausted
+	}
+	link.UsedClicks++
+	return nil
+}
+
+// UpdateLinkOptions décrit les modifications applicables à un lien existant.
+// Les champs nil sont laissés inchangés.
+type UpdateLinkOptions struct {
+	LongURL     *string                // Nouvelle URL de destination
+	Disabled    *bool                  // Active (false) ou désactive (true) la redirection
+	FallbackURL *string                // URL de repli lorsque la destination est inaccessible ("" = repli global)
+	Monitor     *MonitorSettingsUpdate // Réglages de surveillance propres au lien
+}
+
+// MonitorSettingsUpdate décrit les modifications des réglages de surveillance d'un lien.
+// Les champs nil sont laissés inchangés ; une valeur vide (ou un seuil à 0) rétablit le réglage global.
+type MonitorSettingsUpdate struct {
+	Reset               bool    // Rétablit tous les réglages globaux avant d'appliquer les autres champs
+	Enabled             *bool   // Surveillance du lien
+	IntervalMinutes     *int    // Intervalle entre deux vérifications du lien
+	AcceptedStatusCodes *string // Statuts acceptés, ex: "200-299,401"
+	FailureThreshold    *int    // Échecs consécutifs avant de déclarer le lien inaccessible
+	UserAgent           *string // En-tête User-Agent des vérifications
+	GetFallback         *bool   // Repli en GET lorsque HEAD est refusé
+	ContentFingerprint  *bool   // Suivi de l'empreinte du contenu de la destination
 }
 
-// GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics).
-// Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
+// fields valide les modifications et retourne les colonnes à mettre à jour.
+func (u *MonitorSettingsUpdate) fields() (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if u.Reset {
+		fields["monitor_enabled"] = nil
+		fields["monitor_interval_minutes"] = nil
+		fields["monitor_accepted_status_codes"] = ""
+		fields["monitor_failure_threshold"] = nil
+		fields["monitor_user_agent"] = ""
+		fields["monitor_get_fallback"] = nil
+		fields["monitor_content_fingerprint"] = nil
+	}
+	if u.Enabled != nil {
+		fields["monitor_enabled"] = *u.Enabled
+	}
+	if u.IntervalMinutes != nil {
+		interval := *u.IntervalMinutes
+		if interval < 0 || interval > maxIntervalMinutes {
+			return nil, fmt.Errorf("%w: interval must be between 0 and %d minutes", ErrInvalidMonitorSettings, maxIntervalMinutes)
+		}
+		if interval == 0 {
+			fields["monitor_interval_minutes"] = nil
+		} else {
+			fields["monitor_interval_minutes"] = interval
+		}
+	}
+	if u.AcceptedStatusCodes != nil {
+		codes := strings.TrimSpace(*u.AcceptedStatusCodes)
+		if codes != "" {
+			parsed, err := models.ParseStatusCodes(codes)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidMonitorSettings, err)
+			}
+			codes = parsed.String()
+		}
+		fields["monitor_accepted_status_codes"] = codes
+	}
+	if u.FailureThreshold != nil {
+		threshold := *u.FailureThreshold
+		if threshold < 0 || threshold > maxFailureThreshold {
+			return nil, fmt.Errorf("%w: failure threshold must be between 0 and %d", ErrInvalidMonitorSettings, maxFailureThreshold)
+		}
+		if threshold == 0 {
+			fields["monitor_failure_threshold"] = nil
+		} else {
+			fields["monitor_failure_threshold"] = threshold
+		}
+	}
+	if u.UserAgent != nil {
+		userAgent := strings.TrimSpace(*u.UserAgent)
+		if len(userAgent) > maxUserAgentLength || strings.ContainsAny(userAgent, "\r\n") {
+			return nil, fmt.Errorf("%w: user agent must be a single line of at most %d characters", ErrInvalidMonitorSettings, maxUserAgentLength)
+		}
+		fields["monitor_user_agent"] = userAgent
+	}
+	if u.GetFallback != nil {
+		fields["monitor_get_fallback"] = *u.GetFallback
+	}
+	if u.ContentFingerprint != nil {
+		fields["monitor_content_fingerprint"] = *u.ContentFingerprint
+	}
+	return fields, nil
+}
+
+// UpdateLink applique les modifications demandées au lien identifié par son code court
+// et retourne le lien à jour.
+func (s *LinkService) UpdateLink(shortCode string, opts UpdateLinkOptions) (*models.Link, error) {
+	fields := map[string]interface{}{}
+	if opts.LongURL != nil {
+		privateDestination, err := s.checkDestination(*opts.LongURL)
+		if err != nil {
+			return nil, err
+		}
+		fields["long_url"] = *opts.LongURL
+		fields["private_destination"] = privateDestination
+	}
+	if opts.Disabled != nil {
+		// Une action manuelle remplace une éventuelle désactivation automatique par le moniteur
+		fields["disabled"] = *opts.Disabled
+		fields["auto_disabled"] = false
+		fields["disabled_reason"] = ""
+		fields["disabled_at"] = nil
+		if *opts.Disabled {
+			fields["disabled_at"] = time.Now().UTC()
+		} else {
+			// Le moniteur repart de zéro : un lien réactivé à la main n'est pas aussitôt
+			// désactivé de nouveau parce que sa destination était en échec depuis longtemps.
+			fields["reenabled_at"] = time.Now().UTC()
+		}
+	}
+	if opts.FallbackURL != nil {
+		if *opts.FallbackURL != "" {
+			if err := validateFallbackURL(*opts.FallbackURL); err != nil {
+				return nil, err
+			}
+		}
+		fields["fallback_url"] = *opts.FallbackURL
+	}
+	if opts.Monitor != nil {
+		monitorFields, err := opts.Monitor.fields()
+		if err != nil {
+			return nil, err
+		}
+		for column, value := range monitorFields {
+			fields[column] = value
+		}
+	}
+	if len(fields) == 0 {
+		return nil, ErrNothingToUpdate
+	}
+
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	if opts.LongURL != nil && *opts.LongURL != link.LongURL {
+		fields["long_url_changed_at"] = time.Now().UTC()
+	}
+	if err := s.linkRepo.UpdateLink(link.ID, fields); err != nil {
+		return nil, fmt.Errorf("error updating link: %w", err)
+	}
+	return s.linkRepo.GetLinkByID(link.ID)
+}
+
+// SetLinkDisabled active ou désactive la redirection d'un lien.
+func (s *LinkService) SetLinkDisabled(shortCode string, disabled bool) (*models.Link, error) {
+	return s.UpdateLink(shortCode, UpdateLinkOptions{Disabled: &disabled})
+}
+
+// DeleteLink supprime logiquement le lien identifié par son code court.
+// Le code court reste réservé et le lien peut être restauré avec RestoreLink.
+func (s *LinkService) DeleteLink(shortCode string) error {
 	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error retrieving link: %w", err)
+		return err
+	}
+	if err := s.linkRepo.DeleteLink(link.ID); err != nil {
+		return fmt.Errorf("error deleting link: %w", err)
+	}
+	return nil
+}
+
+// RestoreLink restaure un lien précédemment supprimé.
+func (s *LinkService) RestoreLink(shortCode string) (*models.Link, error) {
+	return s.linkRepo.RestoreLink(shortCode)
+}
+
+// ListLinks retourne une page de liens après validation des paramètres de tri, de filtre et
+// de pagination. Une limite nulle est remplacée par la valeur par défaut.
+func (s *LinkService) ListLinks(params repository.LinkListParams) (*repository.LinkPage, error) {
+	if params.Limit == 0 {
+		params.Limit = defaultListLimit
 	}
-	
-	clickCount, err := s.linkRepo.CountClicksByLinkID(link.ID)
+	if params.Limit < 0 || params.Limit > maxListLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListQuery, maxListLimit)
+	}
+
+	switch params.SortBy {
+	case "":
+		params.SortBy = repository.LinkSortCreatedAt
+	case repository.LinkSortCreatedAt, repository.LinkSortClicks:
+	default:
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidListQuery, params.SortBy)
+	}
+
+	switch params.Status {
+	case "", models.LinkStatusActive, models.LinkStatusDisabled, models.LinkStatusExpired, models.LinkStatusDeleted:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidListQuery, params.Status)
+	}
+
+	page, err := s.linkRepo.ListLinks(params)
 	if err != nil {
-		return nil, 0, fmt.Errorf("error counting clicks: %w", err)
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidListQuery, err)
+		}
+		return nil, fmt.Errorf("error listing links: %w", err)
 	}
+	return page, nil
+}
+
+// ClickCounts regroupe le nombre de clics d'un lien, robots inclus (Total) et exclus (Human).
+type ClickCounts struct {
+	Total int
+	Human int
+}
 
-	return link, clickCount, nil
+// Bots retourne le nombre de clics attribués à des robots.
+func (c ClickCounts) Bots() int {
+	return c.Total - c.Human
 }
 
+// GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics et clics humains).
+// Il interagit avec le LinkRepository pour obtenir le lien, puis pour compter ses clics.
+func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, ClickCounts, error) {
+	var counts ClickCounts
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, counts, fmt.Errorf("error retrieving link: %w", err)
+	}
+
+	counts.Total, err = s.linkRepo.CountClicksByLinkID(link.ID)
+	if err != nil {
+		return nil, counts, fmt.Errorf("error counting clicks: %w", err)
+	}
+	counts.Human, err = s.linkRepo.CountHumanClicksByLinkID(link.ID)
+	if err != nil {
+		return nil, counts, fmt.Errorf("error counting human clicks: %w", err)
+	}
+
+	return link, counts, nil
+}
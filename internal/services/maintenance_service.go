@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// ErrInvalidMaintenanceWindow est renvoyée par CreateWindow lorsque la période demandée n'est pas valide.
+var ErrInvalidMaintenanceWindow = errors.New("invalid maintenance window")
+
+// Bornes d'une fenêtre de maintenance.
+const (
+	MaxMaintenanceWindow = 30 * 24 * time.Hour
+	maxMaintenanceReason = 255
+)
+
+// MaintenanceService gère les fenêtres de maintenance pendant lesquelles le moniteur
+// ne notifie pas les changements d'état.
+type MaintenanceService struct {
+	windowRepo repository.MaintenanceWindowRepository
+	linkRepo   repository.LinkRepository
+}
+
+// NewMaintenanceService crée et retourne une nouvelle instance de MaintenanceService.
+func NewMaintenanceService(windowRepo repository.MaintenanceWindowRepository, linkRepo repository.LinkRepository) *MaintenanceService {
+	return &MaintenanceService{windowRepo: windowRepo, linkRepo: linkRepo}
+}
+
+// CreateWindow déclare une fenêtre de maintenance du lien 'shortCode', ou de tous les liens si
+// 'shortCode' est vide. Retourne gorm.ErrRecordNotFound si le lien n'existe pas.
+func (s *MaintenanceService) CreateWindow(shortCode string, startsAt, endsAt time.Time, reason string) (*models.MaintenanceWindow, error) {
+	reason = strings.TrimSpace(reason)
+	switch {
+	case !endsAt.After(startsAt):
+		return nil, fmt.Errorf("%w: end must be after start", ErrInvalidMaintenanceWindow)
+	case !endsAt.After(time.Now()):
+		return nil, fmt.Errorf("%w: end must be in the future", ErrInvalidMaintenanceWindow)
+	case endsAt.Sub(startsAt) > MaxMaintenanceWindow:
+		return nil, fmt.Errorf("%w: window must not exceed %dh", ErrInvalidMaintenanceWindow, int(MaxMaintenanceWindow.Hours()))
+	case len(reason) > maxMaintenanceReason:
+		return nil, fmt.Errorf("%w: reason must be at most %d characters", ErrInvalidMaintenanceWindow, maxMaintenanceReason)
+	}
+
+	window := &models.MaintenanceWindow{StartsAt: startsAt.UTC(), EndsAt: endsAt.UTC(), Reason: reason}
+	var link *models.Link
+	if shortCode != "" {
+		var err error
+		if link, err = s.linkRepo.GetLinkByShortCode(shortCode); err != nil {
+			return nil, err
+		}
+		window.LinkID = &link.ID
+	}
+	if err := s.windowRepo.CreateWindow(window); err != nil {
+		return nil, err
+	}
+	window.Link = link
+	return window, nil
+}
+
+// ListWindows retourne les fenêtres en cours et à venir, ou toutes si 'includeEnded' est vrai.
+func (s *MaintenanceService) ListWindows(includeEnded bool) ([]models.MaintenanceWindow, error) {
+	endingAfter := time.Now()
+	if includeEnded {
+		endingAfter = time.Time{}
+	}
+	return s.windowRepo.ListWindows(endingAfter)
+}
+
+// DeleteWindow supprime une fenêtre de maintenance. Retourne gorm.ErrRecordNotFound si elle n'existe pas.
+func (s *MaintenanceService) DeleteWindow(id uint) error {
+	return s.windowRepo.DeleteWindow(id)
+}
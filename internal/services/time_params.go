@@ -0,0 +1,22 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseTimeParam interprète une date fournie par l'API ou la CLI.
+// Les formats acceptés sont RFC3339 (ex: 2025-06-01T12:00:00Z) et la date seule (ex: 2025-06-01),
+// cette dernière étant interprétée comme minuit dans le fuseau 'loc'.
+func ParseTimeParam(value string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q: expected RFC3339 or YYYY-MM-DD", value)
+}
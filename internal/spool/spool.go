@@ -0,0 +1,605 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// ErrSpoolFull est retournée par Append lorsque la limite d'espace disque du spool est atteinte.
+var ErrSpoolFull = errors.New("click spool is full")
+
+// ErrSpoolClosed est retournée par Append après l'appel à Close.
+var ErrSpoolClosed = errors.New("click spool is closed")
+
+const (
+	segmentPrefix  = "clicks-"
+	segmentSuffix  = ".seg"
+	checkpointFile = "checkpoint.json"
+
+	// checkpointInterval est la fréquence d'écriture du point de reprise sur disque.
+	// Un point de reprise en retard ne fait que rejouer des clics déjà enregistrés au redémarrage.
+	checkpointInterval = time.Second
+	// readChunkSize borne la quantité de données lue à chaque passage du lecteur.
+	readChunkSize = 1 << 20
+	// Délai avant la relivraison d'un événement dont l'enregistrement a échoué, doublé à chaque
+	// nouvel échec jusqu'à maxRetryDelay.
+	initialRetryDelay = time.Second
+	maxRetryDelay     = time.Minute
+)
+
+// Options configure un Spool.
+type Options struct {
+	Dir             string // Dossier contenant les segments et le point de reprise
+	MaxSegmentBytes int64  // Taille à partir de laquelle un nouveau segment est ouvert
+	MaxTotalBytes   int64  // Taille totale maximale des segments (0 = illimitée)
+	SyncWrites      bool   // fsync après chaque écriture (survit à une coupure de courant, plus lent)
+}
+
+// position repère un octet dans la suite des segments.
+type position struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+// pendingEvent est un événement livré aux workers mais pas encore acquitté.
+type pendingEvent struct {
+	end  position // position juste après l'enregistrement
+	done bool
+}
+
+// retryEvent est un événement rendu par un worker (Nack), à relivrer à partir de 'due'.
+type retryEvent struct {
+	due   time.Time
+	event models.ClickEvent
+}
+
+// Spool est une file durable d'événements de clic, intercalée entre le handler de redirection
+// et les workers. Les événements sont ajoutés en fin de segments (une ligne JSON par événement),
+// puis relus par une goroutine qui les pousse dans le channel des workers.
+//
+// Chaque worker acquitte l'événement une fois enregistré (models.ClickEvent.Acknowledge) ; le point
+// de reprise n'avance que sur la partie contiguë des événements acquittés et les segments
+// entièrement traités sont supprimés. Un événement dont l'enregistrement a échoué
+// (models.ClickEvent.Retry) est relivré avec un délai croissant tant que le spool tourne. Au démarrage, tout ce qui suit le point de reprise est rejoué :
+// la livraison est "au moins une fois" (un crash peut rejouer quelques clics déjà enregistrés).
+type Spool struct {
+	opts Options
+
+	mu          sync.Mutex
+	writeFile   *os.File
+	writeSeg    uint64
+	segSizes    map[uint64]int64 // taille de chaque segment présent sur disque
+	totalBytes  int64
+	closed      bool
+	started     bool
+	stopOnce    sync.Once
+	readPos     position
+	checkpoint  position
+	savedPoint  position
+	pending     []pendingEvent
+	pendingBase uint64 // numéro de séquence de pending[0]
+	nextSeq     uint64
+	retries     []retryEvent // événements à relivrer, non acquittés
+
+	notify  chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// Open ouvre (ou crée) le spool situé dans opts.Dir et prépare la relecture des événements
+// non encore acquittés lors de l'exécution précédente.
+func Open(opts Options) (*Spool, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("spool directory is required")
+	}
+	if opts.MaxSegmentBytes <= 0 {
+		return nil, fmt.Errorf("invalid max segment size %d", opts.MaxSegmentBytes)
+	}
+	if opts.MaxTotalBytes < 0 {
+		return nil, fmt.Errorf("invalid max total size %d", opts.MaxTotalBytes)
+	}
+	// Les événements contiennent des données personnelles (User-Agent, référent) : le dossier
+	// et ses fichiers ne sont accessibles qu'au processus.
+	if err := os.MkdirAll(opts.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	if err := os.Chmod(opts.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to restrict spool directory permissions: %w", err)
+	}
+
+	s := &Spool{
+		opts:     opts,
+		segSizes: make(map[uint64]int64),
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// recover relit le point de reprise, inventorie les segments, supprime ceux déjà traités
+// et tronque une éventuelle dernière ligne incomplète (écriture interrompue par un crash).
+func (s *Spool) recover() error {
+	cp, err := s.readCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	ids, err := s.listSegments()
+	if err != nil {
+		return err
+	}
+	var kept []uint64
+	for _, id := range ids {
+		if id < cp.Segment {
+			if err := os.Remove(s.segmentPath(id)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove consumed segment %d: %w", id, err)
+			}
+			continue
+		}
+		kept = append(kept, id)
+	}
+
+	if len(kept) == 0 {
+		s.writeSeg = cp.Segment
+		if s.writeSeg == 0 {
+			s.writeSeg = 1
+		}
+		cp = position{Segment: s.writeSeg}
+	} else {
+		s.writeSeg = kept[len(kept)-1]
+		if err := truncatePartialRecord(s.segmentPath(s.writeSeg)); err != nil {
+			return err
+		}
+		if cp.Segment < kept[0] {
+			cp = position{Segment: kept[0]}
+		}
+	}
+
+	for _, id := range kept {
+		info, err := os.Stat(s.segmentPath(id))
+		if err != nil {
+			return fmt.Errorf("failed to stat segment %d: %w", id, err)
+		}
+		s.segSizes[id] = info.Size()
+		s.totalBytes += info.Size()
+	}
+	if size, ok := s.segSizes[cp.Segment]; ok && cp.Offset > size {
+		cp.Offset = size
+	}
+
+	f, err := os.OpenFile(s.segmentPath(s.writeSeg), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open segment %d: %w", s.writeSeg, err)
+	}
+	if _, ok := s.segSizes[s.writeSeg]; !ok {
+		s.segSizes[s.writeSeg] = 0
+	}
+	s.writeFile = f
+	s.checkpoint = cp
+	s.savedPoint = cp
+	s.readPos = cp
+
+	if backlog := s.backlogLocked(); backlog > 0 {
+		log.Printf("▶ Spool de clics : %d octet(s) d'événements non traités seront rejoués", backlog)
+	}
+	return nil
+}
+
+// Append ajoute un événement en fin de spool. L'appel ne bloque que le temps de l'écriture disque.
+func (s *Spool) Append(event models.ClickEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode click event: %w", err)
+	}
+	line = append(line, '\n')
+	size := int64(len(line))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSpoolClosed
+	}
+	if s.opts.MaxTotalBytes > 0 && s.totalBytes+size > s.opts.MaxTotalBytes {
+		return ErrSpoolFull
+	}
+	if cur := s.segSizes[s.writeSeg]; cur > 0 && cur+size > s.opts.MaxSegmentBytes {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.writeFile.Write(line)
+	s.segSizes[s.writeSeg] += int64(n)
+	s.totalBytes += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write click event: %w", err)
+	}
+	if s.opts.SyncWrites {
+		if err := s.writeFile.Sync(); err != nil {
+			return fmt.Errorf("failed to sync click spool: %w", err)
+		}
+	}
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// rotateLocked ferme le segment courant et en ouvre un nouveau.
+func (s *Spool) rotateLocked() error {
+	if err := s.writeFile.Close(); err != nil {
+		return fmt.Errorf("failed to close segment %d: %w", s.writeSeg, err)
+	}
+	next := s.writeSeg + 1
+	f, err := os.OpenFile(s.segmentPath(next), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open segment %d: %w", next, err)
+	}
+	s.writeFile = f
+	s.writeSeg = next
+	s.segSizes[next] = 0
+	return nil
+}
+
+// Start lance la goroutine qui relit le spool et pousse les événements dans 'out'.
+// L'envoi sur 'out' est bloquant : c'est le spool, et non le channel, qui absorbe les pics.
+func (s *Spool) Start(out chan<- models.ClickEvent) {
+	s.mu.Lock()
+	s.started = true
+	s.mu.Unlock()
+	go s.run(out)
+}
+
+func (s *Spool) run(out chan<- models.ClickEvent) {
+	defer close(s.stopped)
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+
+	for {
+		events := s.dueRetries()
+		fresh, err := s.readAvailable()
+		if err != nil {
+			log.Printf("‼️  Spool de clics — erreur de lecture : %v", err)
+		}
+		events = append(events, fresh...)
+		if len(events) == 0 {
+			select {
+			case <-s.notify:
+			case <-ticker.C:
+				s.saveCheckpoint()
+			case <-s.done:
+				return
+			}
+			continue
+		}
+
+		for _, event := range events {
+			for sent := false; !sent; {
+				select {
+				case out <- event:
+					sent = true
+				case <-ticker.C:
+					s.saveCheckpoint()
+				case <-s.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// readAvailable lit les enregistrements complets disponibles après la position de lecture.
+// Les lignes illisibles sont ignorées (et acquittées d'office) pour ne pas bloquer le spool.
+func (s *Spool) readAvailable() ([]models.ClickEvent, error) {
+	s.mu.Lock()
+	for {
+		size, ok := s.segSizes[s.readPos.Segment]
+		if ok && s.readPos.Offset < size {
+			break
+		}
+		if s.readPos.Segment >= s.writeSeg {
+			s.mu.Unlock()
+			return nil, nil
+		}
+		s.readPos = position{Segment: s.readPos.Segment + 1}
+	}
+	pos := s.readPos
+	limit := s.segSizes[pos.Segment]
+	s.mu.Unlock()
+
+	if limit-pos.Offset > readChunkSize {
+		limit = pos.Offset + readChunkSize
+	}
+	f, err := os.Open(s.segmentPath(pos.Segment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open segment %d: %w", pos.Segment, err)
+	}
+	defer f.Close()
+	buf := make([]byte, limit-pos.Offset)
+	if _, err := f.ReadAt(buf, pos.Offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read segment %d: %w", pos.Segment, err)
+	}
+	if last := bytes.LastIndexByte(buf, '\n'); last >= 0 {
+		buf = buf[:last+1]
+	} else {
+		// Enregistrement plus grand que readChunkSize : ne devrait pas arriver avec des clics.
+		return nil, fmt.Errorf("record larger than %d bytes in segment %d", readChunkSize, pos.Segment)
+	}
+
+	var events []models.ClickEvent
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset := pos.Offset
+	for len(buf) > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		line := buf[:i]
+		buf = buf[i+1:]
+		offset += int64(i + 1)
+
+		seq := s.nextSeq
+		s.nextSeq++
+		s.pending = append(s.pending, pendingEvent{end: position{Segment: pos.Segment, Offset: offset}})
+
+		var event models.ClickEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			log.Printf("⚠️  Spool de clics — enregistrement illisible ignoré (segment %d) : %v", pos.Segment, err)
+			s.ackLocked(seq)
+			continue
+		}
+		event.Ack = s.ackFunc(seq)
+		event.Nack = s.nackFunc(event)
+		events = append(events, event)
+	}
+	s.readPos = position{Segment: pos.Segment, Offset: offset}
+	return events, nil
+}
+
+// ackFunc retourne la fonction d'acquittement de l'événement numéro 'seq'.
+func (s *Spool) ackFunc(seq uint64) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.ackLocked(seq)
+			s.mu.Unlock()
+		})
+	}
+}
+
+// nackFunc retourne la fonction qui programme la relivraison de 'event' après un échec
+// d'enregistrement. Le délai double à chaque échec, jusqu'à maxRetryDelay. L'événement reste
+// non acquitté : si le spool s'arrête avant sa relivraison, il est rejoué au prochain démarrage.
+func (s *Spool) nackFunc(event models.ClickEvent) func() {
+	delay := initialRetryDelay
+	var nack func()
+	nack = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		retry := event
+		retry.Nack = nack
+		s.retries = append(s.retries, retryEvent{due: time.Now().Add(delay), event: retry})
+		delay = min(delay*2, maxRetryDelay)
+	}
+	return nack
+}
+
+// dueRetries retire et retourne les événements dont la relivraison est due.
+func (s *Spool) dueRetries() []models.ClickEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var due []models.ClickEvent
+	kept := s.retries[:0]
+	for _, r := range s.retries {
+		if now.Before(r.due) {
+			kept = append(kept, r)
+			continue
+		}
+		due = append(due, r.event)
+	}
+	s.retries = kept
+	return due
+}
+
+// ackLocked marque l'événement 'seq' comme traité et avance le point de reprise
+// sur la partie contiguë des événements acquittés.
+func (s *Spool) ackLocked(seq uint64) {
+	if seq < s.pendingBase || seq-s.pendingBase >= uint64(len(s.pending)) {
+		return
+	}
+	s.pending[seq-s.pendingBase].done = true
+	for len(s.pending) > 0 && s.pending[0].done {
+		s.checkpoint = s.pending[0].end
+		s.pending = s.pending[1:]
+		s.pendingBase++
+	}
+}
+
+// saveCheckpoint écrit le point de reprise s'il a avancé et supprime les segments entièrement traités.
+func (s *Spool) saveCheckpoint() {
+	s.mu.Lock()
+	cp := s.checkpoint
+	if cp == s.savedPoint {
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	if err := s.writeCheckpoint(cp); err != nil {
+		log.Printf("‼️  Spool de clics — échec d'écriture du point de reprise : %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.savedPoint = cp
+	for id, size := range s.segSizes {
+		if id >= cp.Segment {
+			continue
+		}
+		if err := os.Remove(s.segmentPath(id)); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️  Spool de clics — impossible de supprimer le segment %d : %v", id, err)
+			continue
+		}
+		delete(s.segSizes, id)
+		s.totalBytes -= size
+	}
+}
+
+// Backlog retourne le nombre d'octets d'événements pas encore acquittés.
+func (s *Spool) Backlog() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.backlogLocked()
+}
+
+func (s *Spool) backlogLocked() int64 {
+	var backlog int64
+	for id, size := range s.segSizes {
+		switch {
+		case id > s.checkpoint.Segment:
+			backlog += size
+		case id == s.checkpoint.Segment:
+			backlog += size - s.checkpoint.Offset
+		}
+	}
+	return backlog
+}
+
+// StopReading arrête la goroutine de relecture et attend sa fin : plus aucun événement
+// n'est poussé vers les workers, qui peuvent encore acquitter ceux déjà reçus.
+// Appelée à l'arrêt du serveur avant la fermeture du channel des workers.
+func (s *Spool) StopReading() {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		started := s.started
+		s.mu.Unlock()
+
+		close(s.done)
+		if started {
+			<-s.stopped
+		}
+	})
+}
+
+// Close arrête la relecture, enregistre le point de reprise et ferme le segment courant.
+// Les événements non acquittés seront rejoués à la prochaine ouverture.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	s.StopReading()
+	s.saveCheckpoint()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeFile.Close()
+}
+
+func (s *Spool) segmentPath(id uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%s%020d%s", segmentPrefix, id, segmentSuffix))
+}
+
+// listSegments retourne les numéros des segments présents, triés.
+func (s *Spool) listSegments() ([]uint64, error) {
+	entries, err := os.ReadDir(s.opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spool directory: %w", err)
+	}
+	var ids []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *Spool) readCheckpoint() (position, error) {
+	data, err := os.ReadFile(filepath.Join(s.opts.Dir, checkpointFile))
+	if os.IsNotExist(err) {
+		return position{}, nil
+	}
+	if err != nil {
+		return position{}, fmt.Errorf("failed to read spool checkpoint: %w", err)
+	}
+	var cp position
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return position{}, fmt.Errorf("invalid spool checkpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// writeCheckpoint remplace le point de reprise de manière atomique (fichier temporaire + rename).
+func (s *Spool) writeCheckpoint(cp position) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.opts.Dir, checkpointFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// truncatePartialRecord supprime une éventuelle dernière ligne incomplète d'un segment.
+func truncatePartialRecord(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open segment for recovery: %w", err)
+	}
+	defer f.Close()
+
+	var (
+		valid  int64
+		offset int64
+	)
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		offset += int64(len(line))
+		if err != nil {
+			break
+		}
+		valid = offset
+	}
+	if valid == offset {
+		return nil
+	}
+	log.Printf("⚠️  Spool de clics — enregistrement incomplet tronqué dans %s", filepath.Base(path))
+	return f.Truncate(valid)
+}
@@ -0,0 +1,295 @@
+package spool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// openSpool ouvre un spool dans 'dir' et lance sa relecture vers le channel retourné.
+func openSpool(t *testing.T, opts Options) (*Spool, chan models.ClickEvent) {
+	t.Helper()
+	s, err := Open(opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	out := make(chan models.ClickEvent)
+	s.Start(out)
+	return s, out
+}
+
+func appendEvents(t *testing.T, s *Spool, linkIDs ...uint) {
+	t.Helper()
+	for _, id := range linkIDs {
+		if err := s.Append(models.ClickEvent{LinkID: id, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Append(%d): %v", id, err)
+		}
+	}
+}
+
+// receive lit 'n' événements, en échouant si l'un d'eux n'arrive pas à temps.
+func receive(t *testing.T, out <-chan models.ClickEvent, n int) []models.ClickEvent {
+	t.Helper()
+	events := make([]models.ClickEvent, 0, n)
+	for len(events) < n {
+		select {
+		case event := <-out:
+			events = append(events, event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d event(s), want %d", len(events), n)
+		}
+	}
+	return events
+}
+
+// expectNone vérifie qu'aucun événement supplémentaire n'est livré.
+func expectNone(t *testing.T, out <-chan models.ClickEvent) {
+	t.Helper()
+	select {
+	case event := <-out:
+		t.Fatalf("unexpected event for link %d", event.LinkID)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func linkIDs(events []models.ClickEvent) []uint {
+	ids := make([]uint, len(events))
+	for i, event := range events {
+		ids[i] = event.LinkID
+	}
+	return ids
+}
+
+func assertLinkIDs(t *testing.T, events []models.ClickEvent, want ...uint) {
+	t.Helper()
+	got := linkIDs(events)
+	if len(got) != len(want) {
+		t.Fatalf("link IDs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("link IDs = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestReopenReplaysUnackedEvents(t *testing.T) {
+	opts := Options{Dir: t.TempDir(), MaxSegmentBytes: 1 << 20}
+	s, out := openSpool(t, opts)
+	appendEvents(t, s, 1, 2, 3, 4, 5)
+	events := receive(t, out, 5)
+	assertLinkIDs(t, events, 1, 2, 3, 4, 5)
+	for _, event := range events[:3] {
+		event.Acknowledge()
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, out = openSpool(t, opts)
+	defer s.Close()
+	assertLinkIDs(t, receive(t, out, 2), 4, 5)
+	expectNone(t, out)
+}
+
+func TestAckAfterGapIsReplayed(t *testing.T) {
+	// Le point de reprise n'avance que sur les acquittements contigus : un événement acquitté
+	// après un événement en attente est rejoué (livraison "au moins une fois").
+	opts := Options{Dir: t.TempDir(), MaxSegmentBytes: 1 << 20}
+	s, out := openSpool(t, opts)
+	appendEvents(t, s, 1, 2, 3)
+	events := receive(t, out, 3)
+	events[0].Acknowledge()
+	events[2].Acknowledge()
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, out = openSpool(t, opts)
+	defer s.Close()
+	assertLinkIDs(t, receive(t, out, 2), 2, 3)
+	expectNone(t, out)
+}
+
+func TestSegmentRotationAndCleanup(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Dir: dir, MaxSegmentBytes: 150} // Environ un événement par segment
+	s, out := openSpool(t, opts)
+	appendEvents(t, s, 1, 2, 3, 4)
+	segments, err := s.listSegments()
+	if err != nil {
+		t.Fatalf("listSegments: %v", err)
+	}
+	if len(segments) < 3 {
+		t.Fatalf("got %d segment(s), want rotation into at least 3", len(segments))
+	}
+
+	events := receive(t, out, 4)
+	assertLinkIDs(t, events, 1, 2, 3, 4)
+	for _, event := range events {
+		event.Acknowledge()
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if segments, _ := s.listSegments(); len(segments) != 1 {
+		t.Fatalf("got %d segment(s) after full ack, want only the current one", len(segments))
+	}
+
+	s, out = openSpool(t, opts)
+	defer s.Close()
+	if backlog := s.Backlog(); backlog != 0 {
+		t.Fatalf("Backlog() = %d after reopening, want 0", backlog)
+	}
+	expectNone(t, out)
+}
+
+func TestTruncatedLastRecordIsDropped(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Dir: dir, MaxSegmentBytes: 1 << 20}
+	s, err := Open(opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	appendEvents(t, s, 1, 2)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Écriture interrompue par un crash : dernière ligne sans fin de ligne.
+	path := s.segmentPath(1)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	if _, err := f.WriteString(`{"LinkID":3,"Timest`); err != nil {
+		t.Fatalf("write partial record: %v", err)
+	}
+	f.Close()
+	before, _ := os.Stat(path)
+
+	s, out := openSpool(t, opts)
+	defer s.Close()
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Fatalf("segment size = %d, want partial record truncated (was %d)", after.Size(), before.Size())
+	}
+	assertLinkIDs(t, receive(t, out, 2), 1, 2)
+	expectNone(t, out)
+
+	// Les nouveaux événements sont écrits après les enregistrements complets.
+	appendEvents(t, s, 4)
+	assertLinkIDs(t, receive(t, out, 1), 4)
+}
+
+func TestMaxTotalBytes(t *testing.T) {
+	s, err := Open(Options{Dir: t.TempDir(), MaxSegmentBytes: 1 << 20, MaxTotalBytes: 300})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	var appended int
+	for ; appended < 10; appended++ {
+		err = s.Append(models.ClickEvent{LinkID: uint(appended + 1), Timestamp: time.Now()})
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrSpoolFull) {
+		t.Fatalf("Append error = %v, want ErrSpoolFull", err)
+	}
+	if appended == 0 {
+		t.Fatal("no event appended before the limit")
+	}
+	if backlog := s.Backlog(); backlog > 300 {
+		t.Fatalf("Backlog() = %d, want at most MaxTotalBytes", backlog)
+	}
+}
+
+func TestAppendAfterClose(t *testing.T) {
+	s, err := Open(Options{Dir: t.TempDir(), MaxSegmentBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := s.Append(models.ClickEvent{LinkID: 1}); !errors.Is(err, ErrSpoolClosed) {
+		t.Fatalf("Append error = %v, want ErrSpoolClosed", err)
+	}
+}
+
+func TestRetryRedeliversEvent(t *testing.T) {
+	opts := Options{Dir: t.TempDir(), MaxSegmentBytes: 1 << 20}
+	s, out := openSpool(t, opts)
+	appendEvents(t, s, 1)
+
+	first := receive(t, out, 1)[0]
+	first.Retry()
+	retried := receive(t, out, 1)[0]
+	if retried.LinkID != 1 {
+		t.Fatalf("redelivered link %d, want 1", retried.LinkID)
+	}
+	// Relivré mais pas encore enregistré : toujours dans le backlog.
+	if s.Backlog() == 0 {
+		t.Fatal("Backlog() = 0 before acknowledgement")
+	}
+	retried.Acknowledge()
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, out = openSpool(t, opts)
+	defer s.Close()
+	expectNone(t, out)
+}
+
+func TestUnackedRetryIsReplayedAfterRestart(t *testing.T) {
+	opts := Options{Dir: t.TempDir(), MaxSegmentBytes: 1 << 20}
+	s, out := openSpool(t, opts)
+	appendEvents(t, s, 1, 2)
+	events := receive(t, out, 2)
+	events[0].Retry() // Échec d'enregistrement juste avant l'arrêt
+	events[1].Acknowledge()
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, out = openSpool(t, opts)
+	defer s.Close()
+	assertLinkIDs(t, receive(t, out, 2), 1, 2)
+}
+
+func TestFilePermissions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	s, err := Open(Options{Dir: dir, MaxSegmentBytes: 1 << 20})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	appendEvents(t, s, 1)
+	if err := s.writeCheckpoint(position{Segment: 1}); err != nil {
+		t.Fatalf("writeCheckpoint: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for path, want := range map[string]os.FileMode{
+		dir:                                0o700,
+		s.segmentPath(1):                   0o600,
+		filepath.Join(dir, checkpointFile): 0o600,
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat %s: %v", path, err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %o, want %o", filepath.Base(path), got, want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// Traitements possibles des destinations privées (url_policy.private_destinations).
+const (
+	ModeReject = "reject" // Création refusée
+	ModeFlag   = "flag"   // Lien créé mais signalé
+	ModeAllow  = "allow"  // Aucun contrôle
+)
+
+// ErrPrivateDestination est renvoyée lorsqu'une destination est une adresse privée, de loopback
+// ou link-local qui ne figure pas dans la liste des destinations de confiance.
+var ErrPrivateDestination = errors.New("private network destination")
+
+// lookupTimeout borne la résolution DNS effectuée par CheckURL.
+const lookupTimeout = 2 * time.Second
+
+// reservedNets complète les méthodes de net.IP (loopback, privé, link-local, ...) avec les
+// plages spéciales qui ne désignent pas une destination publique.
+var reservedNets = mustParseCIDRs(
+	"0.0.0.0/8",     // "Ce réseau"
+	"100.64.0.0/10", // Espace partagé (CGNAT)
+	"192.0.0.0/24",  // Affectations de protocole IETF
+	"198.18.0.0/15", // Tests de performance
+	"240.0.0.0/4",   // Réservé, diffusion comprise
+	"64:ff9b::/96",  // Traduction NAT64 (peut cibler une adresse IPv4 privée)
+	"2001:db8::/32", // Documentation
+	"fec0::/10",     // Site-local (obsolète)
+)
+
+// Policy décide si une destination est autorisée. Elle est utilisée à la création des liens
+// (CheckURL) et par le client HTTP du moniteur (DialContext), qui contrôle l'adresse
+// effectivement contactée après résolution DNS et redirections.
+type Policy struct {
+	mode         string
+	allowedHosts []string     // Domaines de confiance, en minuscules et sans point final
+	allowedNets  []*net.IPNet // Plages d'adresses de confiance
+	resolver     *net.Resolver
+}
+
+// NewFromConfig construit la Policy décrite par la section url_policy.
+func NewFromConfig(cfg config.URLPolicyConfig) (*Policy, error) {
+	p := &Policy{mode: strings.ToLower(strings.TrimSpace(cfg.PrivateDestinations)), resolver: net.DefaultResolver}
+	switch p.mode {
+	case "":
+		p.mode = ModeReject
+	case ModeReject, ModeFlag, ModeAllow:
+	default:
+		return nil, fmt.Errorf("url_policy.private_destinations: unknown mode %q (reject, flag or allow)", cfg.PrivateDestinations)
+	}
+	for _, host := range cfg.AllowedHosts {
+		host = strings.Trim(strings.ToLower(strings.TrimSpace(host)), ".")
+		if host != "" {
+			p.allowedHosts = append(p.allowedHosts, host)
+		}
+	}
+	for _, cidr := range cfg.AllowedCIDRs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("url_policy.allowed_cidrs: %w", err)
+		}
+		p.allowedNets = append(p.allowedNets, ipNet)
+	}
+	return p, nil
+}
+
+// Mode retourne le traitement des destinations privées (ModeReject, ModeFlag ou ModeAllow).
+func (p *Policy) Mode() string {
+	return p.mode
+}
+
+// CheckURL vérifie la destination d'une URL : l'hôte, ou chacune des adresses vers lesquelles
+// il est résolu, doit être public ou de confiance. Retourne une erreur enveloppant
+// ErrPrivateDestination sinon. Un hôte impossible à résoudre est accepté : le moniteur
+// contrôlera l'adresse au moment de la connexion.
+func (p *Policy) CheckURL(rawURL string) error {
+	if p.mode == ModeAllow {
+		return nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil // La validité de l'URL est contrôlée ailleurs
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" || p.isAllowedHost(host) {
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrPrivateDestination, host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(ip)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	addrs, err := p.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := p.checkIP(addr.IP); err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// DialContext retourne une fonction de connexion pour http.Transport qui refuse, après résolution
+// DNS, les adresses privées qui ne sont pas de confiance. Les hôtes de confiance sont contactés
+// sans contrôle. Avec ModeAllow, 'dialer' est utilisé tel quel.
+func (p *Policy) DialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if p.mode == ModeAllow {
+		return dialer.DialContext
+	}
+	guarded := *dialer
+	guarded.Control = func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return fmt.Errorf("%w: unexpected address %s", ErrPrivateDestination, address)
+		}
+		return p.checkIP(ip)
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(addr); err == nil && p.isAllowedHost(strings.ToLower(host)) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+}
+
+// checkIP retourne une erreur enveloppant ErrPrivateDestination si l'adresse n'est pas publique
+// et n'appartient à aucune plage de confiance.
+func (p *Policy) checkIP(ip net.IP) error {
+	if !isPrivateIP(ip) {
+		return nil
+	}
+	for _, ipNet := range p.allowedNets {
+		if ipNet.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrPrivateDestination, ip)
+}
+
+// isAllowedHost indique si l'hôte est un domaine de confiance ou l'un de ses sous-domaines.
+func (p *Policy) isAllowedHost(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	for _, allowed := range p.allowedHosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateIP indique si l'adresse ne désigne pas une destination publique : loopback, réseau
+// privé (RFC 1918, ULA), link-local (dont 169.254.169.254), multicast, non spécifiée ou réservée.
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, ipNet := range reservedNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = ipNet
+	}
+	return nets
+}
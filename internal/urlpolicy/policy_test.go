@@ -0,0 +1,225 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// newPolicy construit une Policy à partir de la configuration, en échouant si elle est invalide.
+func newPolicy(t *testing.T, cfg config.URLPolicyConfig) *Policy {
+	t.Helper()
+	p, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewFromConfig: %v", err)
+	}
+	return p
+}
+
+func TestCheckIP(t *testing.T) {
+	p := newPolicy(t, config.URLPolicyConfig{AllowedCIDRs: []string{"10.1.0.0/16", "fd00:1::/32"}})
+
+	tests := []struct {
+		ip      string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"127.8.9.10", true},
+		{"::1", true},
+		{"::ffff:127.0.0.1", true}, // IPv4 mappée en IPv6
+		{"fc00::1", true},          // ULA (fc00::/7)
+		{"fdff:ffff::1", true},
+		{"169.254.169.254", true}, // Métadonnées cloud (link-local)
+		{"fe80::1", true},
+		{"100.64.0.1", true}, // CGNAT (100.64.0.0/10)
+		{"100.127.255.254", true},
+		{"64:ff9b::a00:1", true}, // NAT64 vers 10.0.0.1
+		{"10.0.0.1", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"224.0.0.1", true},
+		{"255.255.255.255", true},
+		{"10.1.2.3", false}, // Plage de confiance
+		{"fd00:1::5", false},
+		{"8.8.8.8", false},
+		{"100.128.0.1", false}, // Juste après 100.64.0.0/10
+		{"2606:4700::1111", false},
+		{"::ffff:8.8.8.8", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("invalid test IP %q", tt.ip)
+			}
+			err := p.checkIP(ip)
+			if tt.blocked && !errors.Is(err, ErrPrivateDestination) {
+				t.Fatalf("checkIP(%s) = %v, want ErrPrivateDestination", tt.ip, err)
+			}
+			if !tt.blocked && err != nil {
+				t.Fatalf("checkIP(%s) = %v, want nil", tt.ip, err)
+			}
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	reject := newPolicy(t, config.URLPolicyConfig{
+		AllowedHosts: []string{"Intranet.Example.com."},
+		AllowedCIDRs: []string{"10.1.0.0/16"},
+	})
+	allow := newPolicy(t, config.URLPolicyConfig{PrivateDestinations: ModeAllow})
+
+	tests := []struct {
+		name    string
+		policy  *Policy
+		url     string
+		blocked bool
+	}{
+		{"loopback", reject, "http://127.0.0.1/", true},
+		{"loopback ipv6", reject, "http://[::1]:8080/admin", true},
+		{"ipv4-mapped loopback", reject, "http://[::ffff:127.0.0.1]/", true},
+		{"ula", reject, "https://[fc00::1]/", true},
+		{"cloud metadata", reject, "http://169.254.169.254/latest/meta-data/", true},
+		{"cgnat", reject, "http://100.64.0.1/", true},
+		{"nat64", reject, "http://[64:ff9b::a00:1]/", true},
+		{"localhost", reject, "http://localhost:8080/", true},
+		{"localhost trailing dot", reject, "http://LOCALHOST./", true},
+		{"localhost subdomain", reject, "http://app.localhost/", true},
+		{"allowed host", reject, "https://intranet.example.com/wiki", false},
+		{"allowed subdomain", reject, "https://docs.INTRANET.example.com./", false},
+		{"allowed host under localhost", reject, "http://intranet.example.com.localhost/", true},
+		{"allowed cidr", reject, "http://10.1.2.3/", false},
+		{"private outside allowed cidr", reject, "http://10.2.0.1/", true},
+		{"public ip", reject, "https://93.184.216.34/", false},
+		{"no host", reject, "/relative/path", false},
+		{"allow mode", allow, "http://127.0.0.1/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.CheckURL(tt.url)
+			if tt.blocked && !errors.Is(err, ErrPrivateDestination) {
+				t.Fatalf("CheckURL(%q) = %v, want ErrPrivateDestination", tt.url, err)
+			}
+			if !tt.blocked && err != nil {
+				t.Fatalf("CheckURL(%q) = %v, want nil", tt.url, err)
+			}
+		})
+	}
+}
+
+// get effectue une requête GET avec un client dont les connexions passent par 'p'.
+func get(p *Policy, rawURL string) error {
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{DialContext: p.DialContext(&net.Dialer{Timeout: time.Second})},
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestDialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+
+	tests := []struct {
+		name    string
+		cfg     config.URLPolicyConfig
+		url     string
+		blocked bool
+	}{
+		{"loopback refused", config.URLPolicyConfig{}, server.URL, true},
+		{"loopback refused in flag mode", config.URLPolicyConfig{PrivateDestinations: ModeFlag}, server.URL, true},
+		{"localhost refused", config.URLPolicyConfig{}, "http://localhost:" + port, true},
+		{"allowed cidr", config.URLPolicyConfig{AllowedCIDRs: []string{"127.0.0.0/8", "::1/128"}}, server.URL, false},
+		{"allowed host", config.URLPolicyConfig{AllowedHosts: []string{"localhost"}}, "http://localhost:" + port, false},
+		{"allow mode", config.URLPolicyConfig{PrivateDestinations: ModeAllow}, server.URL, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := get(newPolicy(t, tt.cfg), tt.url)
+			if tt.blocked && !errors.Is(err, ErrPrivateDestination) {
+				t.Fatalf("GET %s: %v, want ErrPrivateDestination", tt.url, err)
+			}
+			if !tt.blocked && err != nil {
+				t.Fatalf("GET %s: %v, want success", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestDialContextRefusesRedirectToPrivateIP(t *testing.T) {
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer private.Close()
+	// Le premier serveur, de confiance, redirige vers une adresse privée qui ne l'est pas
+	trusted := httptest.NewServer(http.RedirectHandler(private.URL+"/internal", http.StatusFound))
+	defer trusted.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(trusted.URL, "http://"))
+
+	p := newPolicy(t, config.URLPolicyConfig{AllowedHosts: []string{"localhost"}})
+	if err := get(p, "http://localhost:"+port+"/"); !errors.Is(err, ErrPrivateDestination) {
+		t.Fatalf("GET through redirect: %v, want ErrPrivateDestination", err)
+	}
+}
+
+func TestDialContextChecksResolvedAddress(t *testing.T) {
+	// Un nom qui n'est pas de confiance est contrôlé sur l'adresse obtenue après résolution
+	p := newPolicy(t, config.URLPolicyConfig{})
+	dial := p.DialContext(&net.Dialer{Timeout: time.Second})
+	conn, err := dial(context.Background(), "tcp", "localhost:1")
+	if conn != nil {
+		conn.Close()
+	}
+	if !errors.Is(err, ErrPrivateDestination) {
+		t.Fatalf("dial localhost: %v, want ErrPrivateDestination", err)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.URLPolicyConfig
+		wantMode string
+		wantErr  bool
+	}{
+		{"default", config.URLPolicyConfig{}, ModeReject, false},
+		{"case insensitive", config.URLPolicyConfig{PrivateDestinations: " Flag "}, ModeFlag, false},
+		{"unknown mode", config.URLPolicyConfig{PrivateDestinations: "warn"}, "", true},
+		{"invalid cidr", config.URLPolicyConfig{AllowedCIDRs: []string{"10.0.0.0"}}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewFromConfig(tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewFromConfig(%+v) succeeded, want error", tt.cfg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewFromConfig: %v", err)
+			}
+			if p.Mode() != tt.wantMode {
+				t.Fatalf("Mode() = %q, want %q", p.Mode(), tt.wantMode)
+			}
+		})
+	}
+}
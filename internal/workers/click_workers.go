@@ -1,74 +1,187 @@
 package workers
 
 import (
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/analytics"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Nécessaire pour interagir avec le ClickRepository
 )
 
+const (
+	maxRetries       = 3
+	initialBackoffMs = 100
+
+	// Modes d'insertion, utilisés comme label des métriques
+	insertModeSingle = "single"
+	insertModeBatch  = "batch"
+)
+
+// BatchOptions configure le mode d'insertion par lots des workers.
+// Avec Size <= 1, chaque clic est inséré individuellement.
+type BatchOptions struct {
+	Size          int           // Nombre maximal de clics par transaction
+	FlushInterval time.Duration // Délai maximal avant l'écriture d'un lot incomplet
+}
+
 // StartClickWorkers lance un pool de goroutines "workers" pour traiter les événements de clic.
-// Chaque worker lira depuis le même 'clickEventsChan' et utilisera le 'clickRepo' pour la persistance.
-func StartClickWorkers(workerCount int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
+// Chaque worker lira depuis le même 'clickEventsChan', enrichira les événements avec 'enricher'
+// et utilisera le 'clickRepo' pour la persistance, clic par clic ou par lots selon 'batch'.
+// Les workers s'arrêtent une fois 'clickEventsChan' fermé et vidé ; le WaitGroup retourné
+// permet d'attendre la fin de ce drainage.
+func StartClickWorkers(workerCount int, batch BatchOptions, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository, enricher *analytics.Enricher) *sync.WaitGroup {
 	log.Printf("▶ Démarrage de %d worker(s) pour le traitement des clicks...", workerCount)
+	batched := batch.Size > 1
+	if batched {
+		if batch.FlushInterval <= 0 {
+			batch.FlushInterval = time.Second
+		}
+		log.Printf("▶ Insertion par lots : %d click(s) maximum, toutes les %v au plus", batch.Size, batch.FlushInterval)
+	}
+	var wg sync.WaitGroup
 	for i := 0; i < workerCount; i++ {
 		// Lance chaque worker dans sa propre goroutine.
 		// Le channel est passé en lecture seule (<-chan) pour renforcer l'immutabilité du channel à l'intérieur du worker.
 		workerID := i + 1 //  id simple de compréhension
 		log.Printf("▶ Worker %d lancé", workerID)
-		go clickWorker(workerID, clickEventsChan, clickRepo)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if batched {
+				clickBatchWorker(workerID, batch, clickEventsChan, clickRepo, enricher)
+			} else {
+				clickWorker(workerID, clickEventsChan, clickRepo, enricher)
+			}
+		}()
 	}
+	return &wg
 }
 
 // clickWorker est la fonction exécutée par chaque goroutine worker.
-// Elle tourne indéfiniment, lisant les événements de clic dès qu'ils sont disponibles dans le channel.
-// Implémentation : conversion ClickEvent -> models.Click, validation minimale,
-// persistance via clickRepo.CreateClick avec retry/backoff limité.
-func clickWorker(workerID int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository) {
-	const (
-		maxRetries      = 3
-		initialBackoffMs = 100
-	)
-
+// Elle lit les événements de clic dès qu'ils sont disponibles dans le channel, jusqu'à sa fermeture.
+// Implémentation : conversion ClickEvent -> models.Click enrichi, validation minimale,
+// persistance via clickRepo.CreateClick avec retry/backoff limité, puis acquittement de l'événement
+// (le spool durable peut alors avancer son point de reprise). Un clic qui n'a pas pu être enregistré
+// n'est pas acquitté mais rendu au spool, qui le relivrera.
+func clickWorker(workerID int, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository, enricher *analytics.Enricher) {
 	for event := range clickEventsChan { // Boucle qui lit les événements du channel
-		// Conversion ClickEvent -> models.Click
-		click := models.Click{
-			LinkID:    event.LinkID,
-			Timestamp: event.Timestamp,
-			UserAgent: event.UserAgent,
-			IP: event.IP,
+		click, ok := enrichEvent(workerID, enricher, event)
+		if !ok {
+			continue
 		}
 
-		// Validation minimale
-		if click.LinkID == 0 {
-			log.Printf("⚠️  Worker %d — événement de click invalide : LinkID=%d — UserAgent=%q — IP=%q", workerID, click.LinkID, click.UserAgent, click.IP)
+		err := persistWithRetry(workerID, insertModeSingle, fmt.Sprintf("LinkID=%d", click.LinkID), func() error {
+			return clickRepo.CreateClick(&click)
+		})
+		if err != nil {
+			event.Retry()
 			continue
 		}
+		metrics.ClicksStored.Inc()
+		log.Printf("✓ Worker %d — click enregistré (LinkID=%d, ts=%s)", workerID, click.LinkID, click.Timestamp.Format(time.RFC3339))
+		event.Acknowledge()
+	}
+}
 
-		// Persister le click avec retry/backoff simple (meilleure gestion de la surcharge)
-		var err error
-		backoff := time.Duration(initialBackoffMs) * time.Millisecond
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			err = clickRepo.CreateClick(&click)
-			if err == nil {
-				// Succès
-				log.Printf("✓ Worker %d — click enregistré (LinkID=%d, ts=%s)", workerID, click.LinkID, click.Timestamp.Format(time.RFC3339))
-				break
-			}
+// clickBatchWorker accumule les clics enrichis et les enregistre via clickRepo.CreateClicks
+// dès que le lot atteint batch.Size clics ou que batch.FlushInterval s'est écoulé.
+// Le retry/backoff s'applique au lot entier ; les événements ne sont acquittés qu'une fois le lot écrit.
+// En cas d'échec, ils sont rendus au spool durable, qui les relivrera.
+func clickBatchWorker(workerID int, batch BatchOptions, clickEventsChan <-chan models.ClickEvent, clickRepo repository.ClickRepository, enricher *analytics.Enricher) {
+	clicks := make([]models.Click, 0, batch.Size)
+	events := make([]models.ClickEvent, 0, batch.Size)
+	ticker := time.NewTicker(batch.FlushInterval)
+	defer ticker.Stop()
 
-			// Échec — loguer et préparer éventuellement un retry
-			log.Printf("✗ Worker %d — échec enregistrement (LinkID=%d) — tentative %d/%d — erreur: %v", workerID, click.LinkID, attempt, maxRetries, err)
+	flush := func() {
+		if len(clicks) == 0 {
+			return
+		}
+		err := persistWithRetry(workerID, insertModeBatch, fmt.Sprintf("lot de %d click(s)", len(clicks)), func() error {
+			return clickRepo.CreateClicks(clicks)
+		})
+		if err == nil {
+			metrics.ClicksStored.Add(float64(len(clicks)))
+			log.Printf("✓ Worker %d — lot de %d click(s) enregistré", workerID, len(clicks))
+			for _, event := range events {
+				event.Acknowledge()
+			}
+		} else {
+			for _, event := range events {
+				event.Retry()
+			}
+		}
+		clicks = clicks[:0]
+		events = events[:0]
+	}
 
-			if attempt < maxRetries {
-				time.Sleep(backoff)
-				backoff = backoff * 2
+	for {
+		select {
+		case event, open := <-clickEventsChan:
+			if !open {
+				flush()
+				return
+			}
+			click, ok := enrichEvent(workerID, enricher, event)
+			if !ok {
+				continue
 			}
+			clicks = append(clicks, click)
+			events = append(events, event)
+			if len(clicks) >= batch.Size {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
 		}
+	}
+}
 
-		if err != nil {
-			// Après toutes les tentatives, on abandonne et on loggue l'erreur finale.
-			log.Printf("‼️  Worker %d — abandon après %d tentatives pour LinkID=%d — erreur finale: %v", workerID, maxRetries, click.LinkID, err)
+// enrichEvent convertit l'événement en models.Click (référent, User-Agent, détection des robots)
+// et effectue une validation minimale. Un événement invalide est loggué et acquitté.
+func enrichEvent(workerID int, enricher *analytics.Enricher, event models.ClickEvent) (models.Click, bool) {
+	click := enricher.Enrich(event)
+	if click.LinkID == 0 {
+		log.Printf("⚠️  Worker %d — événement de click invalide : LinkID=%d — UserAgent=%q — IP=%q", workerID, click.LinkID, click.UserAgent, click.IP)
+		event.Acknowledge()
+		return click, false
+	}
+	return click, true
+}
+
+// persistWithRetry exécute 'persist' avec retry/backoff simple (meilleure gestion de la surcharge).
+// Après maxRetries échecs, l'erreur finale est logguée et retournée : les clics concernés sont rendus
+// au spool durable pour être relivrés, ou abandonnés sans spool.
+// La durée de chaque tentative et les retries sont comptabilisés dans les métriques.
+func persistWithRetry(workerID int, mode, what string, persist func() error) error {
+	var err error
+	backoff := time.Duration(initialBackoffMs) * time.Millisecond
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		if attempt > 1 {
+			metrics.ClickInsertRetries.Inc()
+		}
+		start := time.Now()
+		err = persist()
+		metrics.ClickInsertDuration.Observe(time.Since(start).Seconds(), mode)
+		if err == nil {
+			return nil
+		}
+
+		// Échec — loguer et préparer éventuellement un retry
+		log.Printf("✗ Worker %d — échec enregistrement (%s) — tentative %d/%d — erreur: %v", workerID, what, attempt, maxRetries, err)
+
+		if attempt < maxRetries {
+			time.Sleep(backoff)
+			backoff = backoff * 2
 		}
 	}
+
+	// Après toutes les tentatives, on abandonne et on loggue l'erreur finale.
+	metrics.ClickInsertFailures.Inc(mode)
+	log.Printf("‼️  Worker %d — abandon après %d tentatives (%s) — erreur finale: %v", workerID, maxRetries, what, err)
+	return err
 }
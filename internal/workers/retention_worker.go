@@ -0,0 +1,38 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// StartRetentionWorker applique périodiquement la politique de rétention des clics bruts.
+// Cette fonction est conçue pour être lancée dans une goroutine séparée : elle exécute une
+// première purge immédiatement, puis une à chaque 'interval', jusqu'à l'annulation de 'ctx'.
+func StartRetentionWorker(ctx context.Context, clickService *services.ClickService, retentionDays int, mode string, interval time.Duration) {
+	log.Printf("▶ Purge des clics activée : rétention de %d jour(s), mode %s, toutes les %v", retentionDays, mode, interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	runRetention(clickService, retentionDays, mode)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runRetention(clickService, retentionDays, mode)
+		}
+	}
+}
+
+// runRetention exécute une purge et loggue son résultat.
+func runRetention(clickService *services.ClickService, retentionDays int, mode string) {
+	count, cutoff, err := clickService.PurgeClicks(retentionDays, mode, false)
+	if err != nil {
+		log.Printf("‼️  Purge des clics — erreur : %v", err)
+		return
+	}
+	log.Printf("✓ Purge des clics — %d click(s) antérieur(s) au %s traité(s) (mode %s)", count, cutoff.Format(time.DateOnly), mode)
+}